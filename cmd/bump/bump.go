@@ -0,0 +1,331 @@
+package bump
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"github.com/pulumi/registrygen/cmd/metadata"
+	"github.com/pulumi/registrygen/pkg"
+	"github.com/spf13/cobra"
+)
+
+// The paths of the registry content relative to the root of a registry
+// checkout.
+const (
+	registryMetadataDir = "themes/default/data/registry/packages"
+	registryContentDir  = "themes/default/content/registry/packages"
+	registryNavsDir     = "themes/default/static/registry/packages/navs"
+	recentUpdatesFile   = "themes/default/data/registry/recent_updates.yaml"
+)
+
+func Command() *cobra.Command {
+	var repoSlug string
+	var version string
+	var packageName string
+	var schemaFile string
+	var registryDir string
+	var branch string
+	var withChangelog bool
+	var skipGit bool
+	var outArchive string
+
+	cmd := &cobra.Command{
+		Use:   "bump",
+		Short: "Update a package in a registry checkout to a new version",
+		Long: "Update a package in a local checkout of the registry to a new version end-to-end: generate its " +
+			"metadata, package docs, API docs and nav tree into the paths the registry expects, then create a git " +
+			"branch and a commit summarizing the version change and the changes to the schema. With --outArchive, the " +
+			"generated files are written to a bundle that can be imported into a registry checkout with import-bundle " +
+			"instead.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			githubSlugParts := strings.Split(repoSlug, "/")
+			if strings.Contains(repoSlug, "https") || strings.Contains(repoSlug, "github.com") || len(githubSlugParts) != 2 {
+				return errors.New(fmt.Sprintf("Expected repoSlug to be in the format of `owner/repo`"+
+					" but got %q", repoSlug))
+			}
+			if packageName == "" {
+				packageName = strings.TrimPrefix(githubSlugParts[1], "pulumi-")
+			}
+
+			if version == "latest" {
+				latest, err := pkg.GetLatestVersion(ctx, repoSlug)
+				if err != nil {
+					return err
+				}
+				version = latest
+			}
+
+			metadataDir := filepath.Join(registryDir, registryMetadataDir)
+			previous, err := readPackageMeta(filepath.Join(metadataDir, packageName+".yaml"))
+			if err != nil {
+				return err
+			}
+			if previous != nil && previous.Version == version {
+				fmt.Printf("%s is already at %s\n", packageName, version)
+				return nil
+			}
+
+			if schemaFile == "" && previous != nil {
+				schemaFile = strings.TrimPrefix(previous.SchemaFilePath, "/")
+			}
+
+			// The files of a bundle are generated into a staging directory
+			// with the layout of the registry.
+			outDir := registryDir
+			if outArchive != "" {
+				outDir, err = os.MkdirTemp("", "registrygen-bundle-")
+				if err != nil {
+					return errors.Wrap(err, "creating the staging directory")
+				}
+				defer os.RemoveAll(outDir)
+			}
+
+			packageDir := filepath.Join(registryContentDir, packageName)
+			metadataFile := filepath.Join(outDir, registryMetadataDir, packageName+".yaml")
+			if outArchive != "" && previous != nil {
+				// The metadata command adds the version to the versions of the
+				// existing metadata.
+				if err := copyFile(filepath.Join(metadataDir, packageName+".yaml"), metadataFile); err != nil {
+					return err
+				}
+			}
+			metadataArgs := []string{
+				"--repoSlug", repoSlug,
+				"--version", version,
+				"--metadataDir", filepath.Dir(metadataFile),
+				"--packageDocsDir", filepath.Join(outDir, packageDir),
+			}
+			if schemaFile != "" {
+				metadataArgs = append(metadataArgs, "--schemaFile", schemaFile)
+			}
+			if withChangelog {
+				metadataArgs = append(metadataArgs, "--withChangelog")
+			}
+			glog.V(2).Infof("Generating the metadata of %s@%s", packageName, version)
+			metadataCmd := metadata.PackageMetadataCmd()
+			metadataCmd.SetArgs(metadataArgs)
+			if err := metadataCmd.ExecuteContext(ctx); err != nil {
+				return errors.Wrap(err, "generating the package metadata")
+			}
+
+			meta, err := readPackageMeta(metadataFile)
+			if err != nil {
+				return err
+			}
+			if meta == nil {
+				return errors.New(fmt.Sprintf("the metadata of %s wasn't generated at %s, use --packageName if "+
+					"the name of the package doesn't match the name of the repository", packageName, metadataFile))
+			}
+
+			loader, err := pkg.NewSchemaLoader(ctx, pkg.SchemaLoaderOptions{
+				RegistryPackagesPath: metadataDir,
+				CacheDir:             pkg.DefaultSchemaCacheDir(),
+			})
+			if err != nil {
+				return err
+			}
+
+			glog.V(2).Infof("Generating the API docs of %s@%s", packageName, version)
+			err = pkg.GenerateDocs(ctx, pkg.GenerateDocsOptions{
+				RepoURL:               meta.RepoURL,
+				Version:               version,
+				SchemaFile:            meta.SchemaFilePath,
+				DocsOutDir:            filepath.Join(outDir, packageDir, "api-docs"),
+				PackageTreeJSONOutDir: filepath.Join(outDir, registryNavsDir),
+
+				PackageStatus:      meta.PackageStatus,
+				DeprecationMessage: meta.DeprecationMessage,
+				SupersededBy:       meta.SupersededBy,
+
+				Languages: meta.Languages,
+
+				SchemaLoader: loader,
+			})
+			if err != nil {
+				return errors.Wrap(err, "generating the API docs")
+			}
+
+			paths := []string{
+				filepath.Join(registryMetadataDir, packageName+".yaml"),
+				packageDir,
+				filepath.Join(registryNavsDir, packageName+".json"),
+			}
+
+			if outArchive != "" {
+				// The recent updates aren't bundled since the bundle would
+				// replace the updates of the other packages in the checkout
+				// that it's imported into.
+				manifest, err := pkg.WriteBundle(outArchive, outDir, pkg.BundleManifest{
+					Package:     packageName,
+					Version:     version,
+					ReplaceDirs: []string{pkg.PackageAPIDocsDir(packageName)},
+				}, paths)
+				if err != nil {
+					return errors.Wrap(err, "writing the bundle")
+				}
+				fmt.Printf("Wrote %d files of %s@%s to %s\n", len(manifest.Files), packageName, version, outArchive)
+				return nil
+			}
+
+			message := commitMessage(ctx, previous, meta)
+			if skipGit {
+				fmt.Print(message)
+				return nil
+			}
+
+			if branch == "" {
+				branch = fmt.Sprintf("registrygen/bump-%s-%s", packageName, version)
+			}
+			if withChangelog {
+				paths = append(paths, recentUpdatesFile)
+			}
+			// The changes aren't committed if the command was interrupted
+			// while the commit message was being written.
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := commitChanges(registryDir, branch, message, paths); err != nil {
+				return err
+			}
+
+			fmt.Printf("Committed the update of %s to %s on branch %s\n", packageName, version, branch)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&repoSlug, "repoSlug", "", "The repository slug e.g. pulumi/pulumi-provider")
+	cmd.Flags().StringVar(&version, "version", "latest", "The version to update the package to, or latest for "+
+		"the latest GitHub release of the repository")
+	cmd.Flags().StringVar(&packageName, "packageName", "", "The name of the package. Defaults to the name of the "+
+		"repository without the pulumi- prefix")
+	cmd.Flags().StringVarP(&schemaFile, "schemaFile", "s", "", "Relative path to the schema file from the root of "+
+		"the repository. Defaults to the schema file in the package's current metadata, if any, or else the schema "+
+		"file found by the metadata command")
+	cmd.Flags().StringVar(&registryDir, "registryDir", ".", "The path to the local checkout of the registry")
+	cmd.Flags().StringVar(&branch, "branch", "", "The name of the git branch to create. Defaults to "+
+		"registrygen/bump-<package>-<version>")
+	cmd.Flags().BoolVar(&withChangelog, "withChangelog", false, "Generate the package's changelog.md and update "+
+		"the registry's recent updates")
+	cmd.Flags().BoolVar(&skipGit, "skipGit", false, "Leave the changes uncommitted and print the commit message "+
+		"instead of creating a branch and a commit")
+
+	cmd.Flags().StringVar(&outArchive, "outArchive", "", "Write the metadata, package docs, API docs and nav tree "+
+		"to a .tar.gz, .tgz or .zip bundle with a manifest.json instead of the registry checkout, which is then only "+
+		"read")
+
+	cmd.MarkFlagRequired("repoSlug")
+
+	return cmd
+}
+
+// readPackageMeta reads a package's metadata file. Returns nil if the file
+// doesn't exist.
+func readPackageMeta(metadataFile string) (*pkg.PackageMeta, error) {
+	b, err := os.ReadFile(metadataFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, fmt.Sprintf("reading the metadata file %s", metadataFile))
+	}
+
+	var meta pkg.PackageMeta
+	if err := yaml.Unmarshal(b, &meta); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("unmarshalling the metadata file %s", metadataFile))
+	}
+	return &meta, nil
+}
+
+// copyFile copies a file, creating the directory of its destination.
+func copyFile(src, dest string) error {
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("reading %s", src))
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("creating the directory of %s", dest))
+	}
+	if err := os.WriteFile(dest, b, 0644); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("writing %s", dest))
+	}
+	return nil
+}
+
+// commitMessage summarizes the version change of a package and the changes
+// to its schema since the previous version, if any.
+func commitMessage(ctx context.Context, previous, meta *pkg.PackageMeta) string {
+	var sb strings.Builder
+	if previous == nil {
+		fmt.Fprintf(&sb, "Add %s %s\n\n", meta.Title, meta.Version)
+		fmt.Fprintf(&sb, "Add the %s package to the registry at %s.\n", meta.Name, meta.Version)
+		return sb.String()
+	}
+
+	fmt.Fprintf(&sb, "Update %s to %s\n\n", meta.Title, meta.Version)
+	fmt.Fprintf(&sb, "Bump the %s package from %s to %s.\n", meta.Name, previous.Version, meta.Version)
+
+	diff, err := diffSchemas(ctx, previous, meta)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: unable to compare the schemas of %s and %s: %v\n",
+			previous.Version, meta.Version, err)
+		return sb.String()
+	}
+	if diff.Empty() {
+		sb.WriteString("\nThe schema is unchanged.\n")
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "\nSchema changes since %s:\n\n", previous.Version)
+	sb.WriteString(diff.String())
+	return sb.String()
+}
+
+func diffSchemas(ctx context.Context, previous, meta *pkg.PackageMeta) (pkg.SchemaDiff, error) {
+	oldSpec, err := pkg.FetchPackageSpec(ctx, previous.RepoURL, previous.Version, previous.SchemaFilePath)
+	if err != nil {
+		return pkg.SchemaDiff{}, err
+	}
+	newSpec, err := pkg.FetchPackageSpec(ctx, meta.RepoURL, meta.Version, meta.SchemaFilePath)
+	if err != nil {
+		return pkg.SchemaDiff{}, err
+	}
+	return pkg.DiffPackageSpecs(oldSpec, newSpec)
+}
+
+// commitChanges creates a branch in the registry checkout and commits the
+// changes to the given paths, relative to the root of the checkout.
+func commitChanges(registryDir, branch, message string, paths []string) error {
+	if err := git(registryDir, "checkout", "-b", branch); err != nil {
+		return err
+	}
+
+	var existing []string
+	for _, p := range paths {
+		if _, err := os.Stat(filepath.Join(registryDir, p)); err == nil {
+			existing = append(existing, p)
+		}
+	}
+	if err := git(registryDir, append([]string{"add", "-A", "--"}, existing...)...); err != nil {
+		return err
+	}
+	return git(registryDir, "commit", "-m", message)
+}
+
+func git(dir string, args ...string) error {
+	var stderr bytes.Buffer
+	c := exec.Command("git", args...)
+	c.Dir = dir
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("running git %s: %s", args[0], strings.TrimSpace(stderr.String())))
+	}
+	return nil
+}
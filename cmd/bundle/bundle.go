@@ -0,0 +1,36 @@
+package bundle
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/registrygen/pkg"
+	"github.com/spf13/cobra"
+)
+
+func ImportCommand() *cobra.Command {
+	var registryDir string
+
+	cmd := &cobra.Command{
+		Use:   "import-bundle <archive>",
+		Short: "Import a bundle of the generated content of a package into a registry checkout",
+		Long: "Verify the files of a bundle written by bump --outArchive against the paths, sizes and SHA-256s of " +
+			"its manifest.json, then unpack them into a local checkout of the registry. Nothing is written if any " +
+			"of the files doesn't match the manifest or isn't a path of the package. The existing API docs of the " +
+			"package are replaced by the bundle's.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manifest, err := pkg.ImportBundle(args[0], registryDir)
+			if err != nil {
+				return errors.Wrap(err, "importing the bundle")
+			}
+			fmt.Printf("Imported %d files of %s@%s into %s\n", len(manifest.Files), manifest.Package,
+				manifest.Version, registryDir)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&registryDir, "registryDir", ".", "The path to the local checkout of the registry")
+
+	return cmd
+}
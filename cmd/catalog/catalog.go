@@ -0,0 +1,65 @@
+package catalog
+
+import (
+	"github.com/pkg/errors"
+	"github.com/pulumi/registrygen/pkg"
+	"github.com/spf13/cobra"
+)
+
+func Command() *cobra.Command {
+	var registryPackagesPath string
+	var outDir string
+	var withCSV bool
+	var skipSchemas bool
+
+	cmd := &cobra.Command{
+		Use:   "catalog",
+		Short: "Generate a catalog of all of the packages in a registry",
+		Long: "Generate a catalog.json describing all of the packages in a registry, grouped by category. " +
+			"The catalog contains the metadata of each package along with its kind, language support and " +
+			"resource counts.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			packages, err := pkg.LoadPackageMetadata(registryPackagesPath)
+			if err != nil {
+				return err
+			}
+
+			catalog, err := pkg.BuildCatalog(ctx, packages, !skipSchemas)
+			if err != nil {
+				return errors.Wrap(err, "building catalog")
+			}
+
+			b, err := catalog.JSON()
+			if err != nil {
+				return errors.Wrap(err, "marshalling catalog")
+			}
+			if err := pkg.EmitFile(outDir, "catalog.json", b); err != nil {
+				return errors.Wrap(err, "writing catalog.json")
+			}
+
+			if !withCSV {
+				return nil
+			}
+
+			b, err = catalog.CSV()
+			if err != nil {
+				return errors.Wrap(err, "generating catalog CSV")
+			}
+			if err := pkg.EmitFile(outDir, "catalog.csv", b); err != nil {
+				return errors.Wrap(err, "writing catalog.csv")
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&registryPackagesPath, "registryPackagesPath", "../registry/themes/default/data/registry/packages/",
+		"The path to the registry metadata files")
+	cmd.Flags().StringVar(&outDir, "outDir", ".", "The directory path to write the catalog to")
+	cmd.Flags().BoolVar(&withCSV, "csv", false, "Also write the catalog as catalog.csv")
+	cmd.Flags().BoolVar(&skipSchemas, "skipSchemas", false, "Don't download the schema of each package. The "+
+		"language support and resource counts are omitted from the catalog")
+
+	return cmd
+}
@@ -0,0 +1,100 @@
+package changelog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/pulumi/registrygen/pkg"
+	"github.com/spf13/cobra"
+)
+
+func Command() *cobra.Command {
+	var repoSlug string
+	var packageName string
+	var fromVersion string
+	var version string
+	var packageDocsDir string
+	var registryPackagesPath string
+	var recentUpdatesFile string
+	var recentUpdatesLimit int
+
+	cmd := &cobra.Command{
+		Use:   "changelog",
+		Short: "Generate a package changelog from its GitHub releases",
+		Long: "Generate the changelog page of a package from the release notes of its GitHub releases and " +
+			"update the registry-wide list of recently updated packages.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if strings.Contains(repoSlug, "https") || strings.Contains(repoSlug, "github.com") {
+				return errors.New(fmt.Sprintf("Expected repoSlug to be in the format of `owner/repo`"+
+					" but got %q", repoSlug))
+			}
+
+			githubSlugParts := strings.Split(repoSlug, "/")
+			if len(githubSlugParts) != 2 {
+				return errors.New(fmt.Sprintf("Expected repoSlug to be in the format of `owner/repo`"+
+					" but got %q", repoSlug))
+			}
+
+			if packageName == "" {
+				packageName = strings.TrimPrefix(githubSlugParts[1], "pulumi-")
+			}
+
+			title := packageName
+			metadataFilePath := filepath.Join(registryPackagesPath, fmt.Sprintf("%s.yaml", packageName))
+			if b, err := os.ReadFile(metadataFilePath); err == nil {
+				var metadata pkg.PackageMeta
+				if err := yaml.Unmarshal(b, &metadata); err != nil {
+					return errors.Wrap(err, fmt.Sprintf("unmarshalling the metadata file %s", metadataFilePath))
+				}
+				title = metadata.Title
+			}
+
+			if packageDocsDir == "" {
+				// if the user hasn't specified an packageDocsDir, we will default to
+				// the path within the registry folder.
+				packageDocsDir = fmt.Sprintf("themes/default/content/registry/packages/%s", packageName)
+			}
+
+			err := pkg.GenerateChangelog(ctx, pkg.ChangelogOptions{
+				RepoSlug:     repoSlug,
+				PackageTitle: title,
+				FromVersion:  fromVersion,
+				ToVersion:    version,
+				OutDir:       packageDocsDir,
+			})
+			if err != nil {
+				return errors.Wrap(err, "generating changelog")
+			}
+
+			if recentUpdatesFile == "" {
+				return nil
+			}
+			return pkg.WriteRecentUpdates(registryPackagesPath, recentUpdatesFile, recentUpdatesLimit)
+		},
+	}
+
+	cmd.Flags().StringVar(&repoSlug, "repoSlug", "", "The repository slug e.g. pulumi/pulumi-provider")
+	cmd.Flags().StringVar(&packageName, "packageName", "", "The name of the package. If omitted, the name is "+
+		"inferred from the repository name")
+	cmd.Flags().StringVar(&fromVersion, "fromVersion", "", "The oldest version to include in the changelog. "+
+		"If omitted, all releases up to --version are included")
+	cmd.Flags().StringVar(&version, "version", "", "The most recent version to include in the changelog. "+
+		"If omitted, all releases since --fromVersion are included")
+	cmd.Flags().StringVar(&packageDocsDir, "packageDocsDir", "", "The location to save the changelog - this will "+
+		"default to the folder structure that the registry expects (themes/default/content/registry/packages/<name>)")
+	cmd.Flags().StringVar(&registryPackagesPath, "registryPackagesPath", "themes/default/data/registry/packages",
+		"The path to the registry metadata files")
+	cmd.Flags().StringVar(&recentUpdatesFile, "recentUpdatesFile", "themes/default/data/registry/recent_updates.yaml",
+		"The data file to write the recently updated packages to. Set to an empty string to skip it")
+	cmd.Flags().IntVar(&recentUpdatesLimit, "recentUpdatesLimit", pkg.DefaultRecentUpdatesLimit, "The number of "+
+		"packages to include in the recent updates. 0 includes all packages")
+
+	cmd.MarkFlagRequired("repoSlug")
+
+	return cmd
+}
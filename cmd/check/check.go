@@ -0,0 +1,38 @@
+package check
+
+import (
+	"github.com/pulumi/registrygen/pkg"
+	"github.com/spf13/cobra"
+)
+
+func Command() *cobra.Command {
+	checkCommand := &cobra.Command{
+		Use:   "check",
+		Short: "Check generated docs for problems",
+	}
+
+	checkCommand.AddCommand(LinksCmd())
+
+	return checkCommand
+}
+
+func LinksCmd() *cobra.Command {
+	var registryDocsDir string
+
+	cmd := &cobra.Command{
+		Use:   "links <dir>...",
+		Short: "Check the internal links of generated docs",
+		Long: "Check the relative and registry-absolute links and the anchors of the Markdown pages in each " +
+			"directory and report the broken links with their source file and line.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return pkg.CheckLinks(registryDocsDir, args...)
+		},
+	}
+
+	cmd.Flags().StringVar(&registryDocsDir, "registryDocsDir", "", "The directory path that the docs of the "+
+		"packages are served from under /registry/packages/, e.g. content/registry/packages. The links to "+
+		"/registry/packages/ aren't checked if omitted")
+
+	return cmd
+}
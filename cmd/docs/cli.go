@@ -4,12 +4,15 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
-	"github.com/ghodss/yaml"
+	"github.com/golang/glog"
 	"github.com/pulumi/registrygen/pkg"
 	"github.com/spf13/cobra"
 )
 
+const defaultBaseURL = "https://www.pulumi.com"
+
 func GenerateCommand() *cobra.Command {
 	generateCommand := &cobra.Command{
 		Use:   "generate",
@@ -29,40 +32,134 @@ func AllPackageDocsCmd() *cobra.Command {
 	var registryPackagesPath string
 	var baseDocsOutDir string
 	var packageTreeJSONOutDir string
+	var versioned bool
+	var keepVersions int
+	var searchIndexOutDir string
+	var sitemapOutDir string
+	var baseURL string
+	var languages []string
+	var checkLinks bool
+	var schemaCacheDir string
+	var format string
 
 	cmd := &cobra.Command{
 		Use:   "all-docs",
 		Short: "Generate API docs for an entire registry",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			output, err := outputAdapter(format, checkLinks)
+			if err != nil {
+				return err
+			}
+
+			var sitemap *pkg.Sitemap
+			if sitemapOutDir != "" {
+				sitemap = pkg.NewSitemap(baseURL)
+			}
 
-			metadataFiles, err := os.ReadDir(registryPackagesPath)
+			packages, err := pkg.LoadPackageMetadata(registryPackagesPath)
 			if err != nil {
-				return fmt.Errorf("reading the registry packages dir: %w", err)
+				return err
 			}
 
-			for _, packageMetadata := range metadataFiles {
-				metadataFilePath := filepath.Join(registryPackagesPath, packageMetadata.Name())
+			// The loader is shared by all of the packages so that the packages
+			// they reference are only loaded once.
+			loader, err := pkg.NewSchemaLoader(ctx, pkg.SchemaLoaderOptions{
+				RegistryPackagesPath: registryPackagesPath,
+				CacheDir:             schemaCacheDir,
+			})
+			if err != nil {
+				return err
+			}
 
-				b, err := os.ReadFile(metadataFilePath)
-				if err != nil {
-					return fmt.Errorf("reading the metadata file %s: %w", metadataFilePath, err)
-				}
+			names := make([]string, 0, len(packages))
+			for _, metadata := range packages {
+				names = append(names, metadata.Name)
+			}
+			progress := pkg.NewProgress("generating the docs of", names)
+			defer progress.ReportCanceled(ctx, os.Stderr)
 
-				var metadata pkg.PackageMeta
-				if err := yaml.Unmarshal(b, &metadata); err != nil {
-					return fmt.Errorf("unmarshalling the metadata file %s: %w", metadataFilePath, err)
+			var generatedDirs []string
+			for _, metadata := range packages {
+				if err := ctx.Err(); err != nil {
+					return err
 				}
-
 				if metadata.RepoURL == "" {
 					return fmt.Errorf("metadata for package %q does not contain the repo_url", metadata.Name)
 				}
 
+				versions := []string{metadata.Version}
+				if versioned {
+					versions, err = pkg.MergeVersions(metadata.Versions, metadata.Version)
+					if err != nil {
+						return fmt.Errorf("getting the versions of package %q: %w", metadata.Name, err)
+					}
+					if keepVersions > 0 && len(versions) > keepVersions {
+						versions = versions[:keepVersions]
+					}
+				}
+
+				// The languages passed on the command line take precedence over
+				// the languages in the package's metadata.
+				packageLanguages := metadata.Languages
+				if len(languages) > 0 {
+					packageLanguages = languages
+				}
+
 				docsOutDir := filepath.Join(baseDocsOutDir, metadata.Name, "api-docs")
-				if err := pkg.GenerateDocs(metadata.RepoURL, metadata.Version, metadata.SchemaFilePath, docsOutDir, packageTreeJSONOutDir); err != nil {
-					return fmt.Errorf("error generating docs for %s: %w", metadata.Name, err)
+				// Generate the oldest version first so that the latest version
+				// ends up being the one in the unversioned nav tree.
+				for i := len(versions) - 1; i >= 0; i-- {
+					opts := pkg.GenerateDocsOptions{
+						RepoURL:               metadata.RepoURL,
+						Version:               versions[i],
+						SchemaFile:            metadata.SchemaFilePath,
+						DocsOutDir:            docsOutDir,
+						PackageTreeJSONOutDir: packageTreeJSONOutDir,
+
+						PackageStatus:      metadata.PackageStatus,
+						DeprecationMessage: metadata.DeprecationMessage,
+						SupersededBy:       metadata.SupersededBy,
+
+						Versioned:    versioned,
+						KeepVersions: keepVersions,
+
+						SearchIndexOutDir: searchIndexOutDir,
+
+						Sitemap:   sitemap,
+						UpdatedOn: metadata.UpdatedOn,
+
+						Languages: packageLanguages,
+
+						SchemaLoader: loader,
+
+						Output: output,
+					}
+					if err := pkg.GenerateDocs(ctx, opts); err != nil {
+						return fmt.Errorf("error generating docs for %s@%s: %w", metadata.Name, versions[i], err)
+					}
+				}
+				generatedDirs = append(generatedDirs, docsOutDir)
+				progress.Done(metadata.Name)
+			}
+
+			if sitemap != nil {
+				if err := sitemap.Write(sitemapOutDir); err != nil {
+					return err
+				}
+			}
+
+			if output != nil {
+				if err := output.Write(baseDocsOutDir); err != nil {
+					return err
 				}
 			}
 
+			// The links are checked once all of the packages are generated so
+			// that the links between packages can be resolved.
+			if checkLinks {
+				return pkg.CheckLinks(baseDocsOutDir, generatedDirs...)
+			}
 			return nil
 		},
 	}
@@ -71,31 +168,177 @@ func AllPackageDocsCmd() *cobra.Command {
 	cmd.Flags().StringVar(&baseDocsOutDir, "docsOutDir", "content/registry/packages", "The directory path to where the docs will be written to")
 	cmd.Flags().StringVar(&packageTreeJSONOutDir, "packageTreeJSONOutDir", "static/registry/packages/navs", "The directory path to write the "+
 		"package tree JSON file to")
+	cmd.Flags().BoolVar(&versioned, "versioned", false, "Generate the docs of every version listed in the package "+
+		"metadata into per-major-version directories instead of only the docs of the current version")
+	cmd.Flags().IntVar(&keepVersions, "keepVersions", 0, "The number of major versions to keep the docs for when "+
+		"--versioned is set. 0 keeps all of them")
+	cmd.Flags().StringVar(&searchIndexOutDir, "searchIndexOutDir", "", "The directory path to write the search "+
+		"index shards and their manifest to. No search index is generated if omitted")
+	cmd.Flags().StringVar(&sitemapOutDir, "sitemapOutDir", "", "The directory path to write the sitemap of the "+
+		"API docs to. No sitemap is generated if omitted")
+	cmd.Flags().StringVar(&baseURL, "baseURL", defaultBaseURL, "The base URL of the registry used for the URLs "+
+		"in the sitemap")
+	cmd.Flags().StringSliceVar(&languages, "languages", nil, "The languages to render in the docs of all "+
+		"packages, e.g. nodejs,python. Defaults to the languages in each package's metadata, or else the languages "+
+		"in the language section of the package's schema")
+	cmd.Flags().BoolVar(&checkLinks, "checkLinks", false, "Check the links of the generated docs once all of the "+
+		"packages are generated and fail if any of them are broken")
+	cmd.Flags().StringVar(&schemaCacheDir, "schemaCacheDir", pkg.DefaultSchemaCacheDir(), "The directory to cache the schemas of the "+
+		"packages referenced by the schema in")
+	cmd.Flags().StringVar(&format, "format", string(pkg.DocsFormatMarkdown), "The format of the docs: "+
+		"markdown for the registry, html for a standalone static site with an index page of all of the packages, "+
+		"docusaurus for MDX pages and a sidebars.js, or mkdocs for Markdown pages and an mkdocs.yml")
 
 	return cmd
 }
 
 func PackageDocsCmd() *cobra.Command {
-	var schemaFile string
+	var schemaFiles []string
 	var repoSlug string
 	var version string
 	var docsOutDir string
 	var packageTreeJSONOutDir string
+	var deprecationMessage string
+	var supersededBy string
+	var versioned bool
+	var keepVersions int
+	var searchIndexOutDir string
+	var sitemapOutDir string
+	var baseURL string
+	var languages []string
+	var checkLinks bool
+	var registryDocsDir string
+	var registryPackagesPath string
+	var schemaCacheDir string
+	var format string
 
 	cmd := &cobra.Command{
 		Use:   "docs",
 		Short: "Generate API Docs docs from a Pulumi schema file",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return pkg.GenerateDocs(repoSlug, version, schemaFile, docsOutDir, packageTreeJSONOutDir)
+			ctx := cmd.Context()
+			output, err := outputAdapter(format, checkLinks)
+			if err != nil {
+				return err
+			}
+
+			var sitemap *pkg.Sitemap
+			var updatedOn int64
+			if sitemapOutDir != "" {
+				sitemap = pkg.NewSitemap(baseURL)
+
+				// The pages are last modified by the release whose docs are
+				// generated, or now if the release can't be found.
+				publishedDate := time.Now()
+				if repoSlug != "" && version != "" {
+					releaseDate, err := pkg.GetReleaseDate(ctx, repoSlug, version)
+					if err != nil {
+						glog.Warningf("Using the current date as the last modification date of the sitemap: %v", err)
+					} else {
+						publishedDate = releaseDate
+					}
+				}
+				updatedOn = publishedDate.Unix()
+			}
+
+			loader, err := pkg.NewSchemaLoader(ctx, pkg.SchemaLoaderOptions{
+				RegistryPackagesPath: registryPackagesPath,
+				CacheDir:             schemaCacheDir,
+			})
+			if err != nil {
+				return err
+			}
+
+			progress := pkg.NewProgress("generating the docs of", schemaFiles)
+			defer progress.ReportCanceled(ctx, os.Stderr)
+
+			for _, schemaFile := range schemaFiles {
+				err := pkg.GenerateDocs(ctx, pkg.GenerateDocsOptions{
+					RepoURL:               repoSlug,
+					Version:               version,
+					SchemaFile:            schemaFile,
+					DocsOutDir:            docsOutDir,
+					PackageTreeJSONOutDir: packageTreeJSONOutDir,
+					PerPackageDocsOutDir:  len(schemaFiles) > 1,
+
+					DeprecationMessage: deprecationMessage,
+					SupersededBy:       supersededBy,
+
+					Versioned:    versioned,
+					KeepVersions: keepVersions,
+
+					SearchIndexOutDir: searchIndexOutDir,
+
+					Sitemap:   sitemap,
+					UpdatedOn: updatedOn,
+
+					Languages: languages,
+
+					SchemaLoader: loader,
+
+					Output: output,
+				})
+				if err != nil {
+					return fmt.Errorf("error generating docs for %s: %w", schemaFile, err)
+				}
+				progress.Done(schemaFile)
+			}
+
+			if sitemap != nil {
+				if err := sitemap.Write(sitemapOutDir); err != nil {
+					return err
+				}
+			}
+
+			if output != nil {
+				if err := output.Write(docsOutDir); err != nil {
+					return err
+				}
+			}
+
+			if checkLinks {
+				return pkg.CheckLinks(registryDocsDir, docsOutDir)
+			}
+			return nil
 		},
 	}
 
-	cmd.Flags().StringVarP(&schemaFile, "schemaFile", "s", "", "Path to the schema.json file")
+	cmd.Flags().StringSliceVarP(&schemaFiles, "schemaFile", "s", nil, "Path to the schema.json file. Can be "+
+		"specified multiple times for repositories that publish multiple packages, in which case the docs of each "+
+		"package are written to a sub-directory of docsOutDir named after the package")
 	cmd.Flags().StringVar(&repoSlug, "repoSlug", "", "The repository slug e.g. pulumi/pulumi-provider")
 	cmd.Flags().StringVar(&version, "version", "", "The version of the package")
 	cmd.Flags().StringVar(&docsOutDir, "docsOutDir", "", "The directory path to where the docs will be written to")
 	cmd.Flags().StringVar(&packageTreeJSONOutDir, "packageTreeJSONOutDir", "", "The directory path to write the "+
 		"package tree JSON file to")
+	cmd.Flags().StringVar(&deprecationMessage, "deprecationMessage", "", "An optional message to show in the banner "+
+		"of a deprecated package")
+	cmd.Flags().StringVar(&supersededBy, "supersededBy", "", "The name of the package that replaces a deprecated package")
+	cmd.Flags().BoolVar(&versioned, "versioned", false, "Write the docs to a sub-directory of docsOutDir named "+
+		"after the major version and keep the docs of the other major versions")
+	cmd.Flags().IntVar(&keepVersions, "keepVersions", 0, "The number of major versions to keep the docs for when "+
+		"--versioned is set. 0 keeps all of them")
+	cmd.Flags().StringVar(&searchIndexOutDir, "searchIndexOutDir", "", "The directory path to write the search "+
+		"index shard and its manifest to. No search index is generated if omitted")
+	cmd.Flags().StringVar(&sitemapOutDir, "sitemapOutDir", "", "The directory path to write the sitemap of the "+
+		"API docs to. No sitemap is generated if omitted")
+	cmd.Flags().StringVar(&baseURL, "baseURL", defaultBaseURL, "The base URL of the registry used for the URLs "+
+		"in the sitemap")
+	cmd.Flags().StringSliceVar(&languages, "languages", nil, "The languages to render in the docs, e.g. "+
+		"nodejs,python. Defaults to the languages in the language section of the package's schema")
+	cmd.Flags().BoolVar(&checkLinks, "checkLinks", false, "Check the links of the generated docs and fail if any of "+
+		"them are broken")
+	cmd.Flags().StringVar(&registryDocsDir, "registryDocsDir", "", "The directory path that the docs of the "+
+		"packages are served from under /registry/packages/, used to check the links to other packages. The "+
+		"links to other packages aren't checked if omitted")
+	cmd.Flags().StringVar(&registryPackagesPath, "registryPackagesPath", "", "The path to the registry metadata "+
+		"files, used to find the repositories of the packages referenced by the schema. Packages without metadata "+
+		"are assumed to be in pulumi/pulumi-<name>")
+	cmd.Flags().StringVar(&schemaCacheDir, "schemaCacheDir", pkg.DefaultSchemaCacheDir(), "The directory to cache the schemas of the "+
+		"packages referenced by the schema in")
+	cmd.Flags().StringVar(&format, "format", string(pkg.DocsFormatMarkdown), "The format of the docs: "+
+		"markdown for the registry, html for a standalone static site, docusaurus for MDX pages and a sidebars.js, "+
+		"or mkdocs for Markdown pages and an mkdocs.yml")
 
 	cmd.MarkFlagRequired("repoSlug")
 	cmd.MarkFlagRequired("docsOutDir")
@@ -105,3 +348,26 @@ func PackageDocsCmd() *cobra.Command {
 
 	return cmd
 }
+
+// outputAdapter returns the adapter that writes the docs in the given format,
+// or nil if the docs are written as the Markdown of the registry.
+func outputAdapter(format string, checkLinks bool) (pkg.OutputAdapter, error) {
+	var output pkg.OutputAdapter
+	switch pkg.DocsFormat(format) {
+	case pkg.DocsFormatMarkdown:
+		return nil, nil
+	case pkg.DocsFormatHTML:
+		output = pkg.NewHTMLSite()
+	case pkg.DocsFormatDocusaurus:
+		output = pkg.NewDocusaurusAdapter()
+	case pkg.DocsFormatMkDocs:
+		output = pkg.NewMkDocsAdapter()
+	default:
+		return nil, fmt.Errorf("unknown format %q, expected one of %s, %s, %s or %s", format, pkg.DocsFormatMarkdown,
+			pkg.DocsFormatHTML, pkg.DocsFormatDocusaurus, pkg.DocsFormatMkDocs)
+	}
+	if checkLinks {
+		return nil, fmt.Errorf("--checkLinks is only supported for the markdown format")
+	}
+	return output, nil
+}
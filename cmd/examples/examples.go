@@ -0,0 +1,75 @@
+package examples
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/registrygen/pkg"
+	"github.com/spf13/cobra"
+)
+
+func Command() *cobra.Command {
+	examplesCommand := &cobra.Command{
+		Use:   "examples",
+		Short: "Work with the examples in a Pulumi schema",
+	}
+
+	examplesCommand.AddCommand(ExtractCmd())
+
+	return examplesCommand
+}
+
+func ExtractCmd() *cobra.Command {
+	var schemaFile string
+	var repoSlug string
+	var version string
+	var outDir string
+	var schemaCacheDir string
+
+	cmd := &cobra.Command{
+		Use:   "extract",
+		Short: "Extract the examples of a Pulumi schema into source files",
+		Long: "Extract the code snippets of the examples in the descriptions of the resources and functions of " +
+			"a Pulumi schema into <outDir>/<token>/<example-title>/<lang>/main.<ext> files, along with a " +
+			"manifest.json listing them, so that they can be compiled and linted.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if repoSlug != "" && version == "" {
+				return errors.New("version is required when repoSlug is specified")
+			}
+
+			loader, err := pkg.NewSchemaLoader(ctx, pkg.SchemaLoaderOptions{CacheDir: schemaCacheDir})
+			if err != nil {
+				return errors.Wrap(err, "creating the schema loader")
+			}
+
+			pulPkg, err := pkg.LoadPackage(ctx, schemaFile, repoSlug, version, loader)
+			if err != nil {
+				return errors.Wrap(err, "loading schema")
+			}
+
+			examples := pkg.GetExamples(pulPkg)
+			manifest, err := pkg.ExtractExamples(outDir, examples)
+			if err != nil {
+				return errors.Wrap(err, "extracting examples")
+			}
+
+			fmt.Printf("Extracted %d examples into %d files\n", len(examples), len(manifest.Examples))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&schemaFile, "schemaFile", "s", "", "Path to the schema.json file. If repoSlug is "+
+		"specified, the path is relative to the root of the repository")
+	cmd.Flags().StringVar(&repoSlug, "repoSlug", "", "The repository slug e.g. pulumi/pulumi-provider. If omitted, "+
+		"schemaFile is read from the local filesystem")
+	cmd.Flags().StringVar(&version, "version", "", "The version of the package. Required with repoSlug")
+	cmd.Flags().StringVar(&outDir, "outDir", "", "The directory path to write the examples to")
+	cmd.Flags().StringVar(&schemaCacheDir, "schemaCacheDir", pkg.DefaultSchemaCacheDir(), "The directory to cache the schemas of the "+
+		"packages referenced by the schema in")
+
+	cmd.MarkFlagRequired("schemaFile")
+	cmd.MarkFlagRequired("outDir")
+
+	return cmd
+}
@@ -1,11 +1,14 @@
 package metadata
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"net/http"
+	"os"
+	"path"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -22,8 +25,6 @@ import (
 
 const defaultPackageCategory = pkg.PackageCategoryCloud
 
-var mainSpec *pschema.PackageSpec
-
 var featuredPackages = []string{
 	"aws",
 	"azure-native",
@@ -37,16 +38,30 @@ func PackageMetadataCmd() *cobra.Command {
 	var categoryStr string
 	var component bool
 	var publisher string
-	var schemaFile string
+	var schemaFiles []string
+	var pluginManifests []string
 	var title string
 	var version string
 	var metadataDir string
 	var packageDocsDir string
+	var statusStr string
+	var deprecationMessage string
+	var supersededBy string
+	var withChangelog bool
+	var frontMatterRulesFile string
+	var fixFrontMatter bool
+	var strictFrontMatter bool
+	var canonicalBaseURL string
+	var syncDocs bool
+	var docsStaticDir string
+	var docsStaticURLPath string
+	var docsNavOutDir string
 
 	cmd := &cobra.Command{
 		Use:   "metadata <args>",
 		Short: "Generate package metadata from Pulumi schema",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
 
 			if strings.Contains(repoSlug, "https") || strings.Contains(repoSlug, "github.com") {
 				return errors.New(fmt.Sprintf("Expected repoSlug to be in the format of `owner/repo`"+
@@ -64,39 +79,47 @@ func PackageMetadataCmd() *cobra.Command {
 					" but got %q", repoSlug))
 			}
 
-			if schemaFile == "" && providerName == "" {
-				providerName = strings.Replace(repoName, "pulumi-", "", -1)
+			if len(schemaFiles) > 0 && len(pluginManifests) > 0 {
+				return errors.New("schemaFile and pluginManifest can't be specified together")
 			}
 
-			if schemaFile == "" {
-				schemaFile = fmt.Sprintf("provider/cmd/pulumi-resource-%s/schema.json", providerName)
+			var packages []packageSource
+			for _, schemaFile := range schemaFiles {
+				packages = append(packages, packageSource{schemaFile: schemaFile})
 			}
-
-			// we should be able to take the repo URL + the version + the schema url and
-			// construct a file that we can download and read
-			schemaFilePath := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s",
-				repoSlug, version, schemaFile)
-
-			schema, err := readRemoteFile(schemaFilePath)
-			if err != nil {
-				return err
+			if len(packages) == 0 && (len(pluginManifests) > 0 || providerName == "") {
+				// Plugin-based providers and components describe themselves
+				// in a plugin manifest which tells where their schema is.
+				manifests, err := getPluginManifests(ctx, repoSlug, version, pluginManifests)
+				if err != nil {
+					return err
+				}
+				for _, m := range manifests {
+					schemaFile, err := m.ResolveSchemaFile(ctx, repoSlug, version)
+					if err != nil {
+						return err
+					}
+					packages = append(packages, packageSource{schemaFile: schemaFile, manifest: m})
+				}
 			}
-
-			if schema == nil {
-				return fmt.Errorf("unable to get contents of schemaFile %q", schemaFilePath)
+			if len(packages) == 0 {
+				if providerName == "" {
+					providerName = strings.Replace(repoName, "pulumi-", "", -1)
+				}
+				packages = []packageSource{{
+					schemaFile: fmt.Sprintf("provider/cmd/pulumi-resource-%s/schema.json", providerName),
+				}}
 			}
 
-			// The source schema can be in YAML format. If that's the case
-			// convert it to JSON first.
-			if strings.HasSuffix(schemaFile, ".yaml") {
-				schema, err = yaml.YAMLToJSON(schema)
-				if err != nil {
-					return errors.Wrap(err, "reading YAML schema")
-				}
+			// Repos that publish multiple packages keep the companion docs of
+			// each package in a sub-directory of docs/ named after it.
+			multiplePackages := len(packages) > 1
+			if multiplePackages && title != "" {
+				return errors.New("title can't be specified with multiple schema files")
 			}
 
 			// try and get the version release data using the github releases API
-			tags, err := getGitHubTags(repoSlug)
+			tags, err := getGitHubTags(ctx, repoSlug)
 			if err != nil {
 				return errors.Wrap(err, "github tags")
 			}
@@ -113,7 +136,7 @@ func PackageMetadataCmd() *cobra.Command {
 			if commitDetails != "" {
 				var commit pkg.GitHubCommit
 				// now let's make a request to the specific commit to get the date
-				commitResp, err := http.Get(commitDetails)
+				commitResp, err := pkg.HTTPGet(ctx, commitDetails)
 				if err != nil {
 					return errors.Wrap(err, fmt.Sprintf("getting release info for %s", repoSlug))
 				}
@@ -127,135 +150,69 @@ func PackageMetadataCmd() *cobra.Command {
 				publishedDate = commit.Commit.Author.Date
 			}
 
-			mainSpec = &pschema.PackageSpec{}
-			if err := json.Unmarshal(schema, mainSpec); err != nil {
-				return errors.Wrap(err, "unmarshalling schema into a PackageSpec")
-			}
-			mainSpec.Version = version
-
-			if mainSpec.Repository == "" {
-				// we already know the repo slug so we can reconstruct the repository name using that
-				mainSpec.Repository = fmt.Sprintf("https://github.com/%s", repoSlug)
-			}
-
-			status := pkg.PackageStatusGA
-			if strings.HasPrefix(version, "v0.") {
-				status = pkg.PackageStatusPublicPreview
-			}
-
-			category, err := getPackageCategory(mainSpec, categoryStr)
-			if err != nil {
-				return errors.Wrap(err, "getting category")
-			}
-
-			// If the title was not overridden, then try to determine
-			// the title from the schema.
-			if title == "" {
-				// If the schema for this package does not have the
-				// displayName, then use its package name.
-				if mainSpec.DisplayName == "" {
-					title = mainSpec.Name
-					// Eventually all of Pulumi's own packages will have the displayName
-					// set in their schema but for the time being until they are updated
-					// with that info, let's lookup the proper title from the lookup map.
-					if v, ok := pkg.TitleLookup[mainSpec.Name]; ok {
-						title = v
-					}
-				} else {
-					title = mainSpec.DisplayName
-				}
-			}
-
-			native := mainSpec.Attribution == ""
-			// If native is false, check if the schema has the "kind/native" tag in the Keywords
-			// array.
-			if !native {
-				native = isNative(mainSpec.Keywords)
-			}
-
-			if !component {
-				component = isComponent(mainSpec.Keywords)
-			}
-
-			if native && component {
-				native = false
-			}
-
-			// if there's a publisher then we need to use that immediately
-			// if there is no publisher on cmd, then try and use packageSpec
-			// if there's no publisher or packageSpec publisher, then assume repo owner is the publisher
-			// otherwise error
-			publisherName := ""
-			if publisher != "" {
-				publisherName = publisher
-			} else if publisher == "" && mainSpec.Publisher != "" {
-				publisherName = mainSpec.Publisher
-			} else if publisher == "" && repoOwner != "" {
-				publisherName = cases.Title(language.Und, cases.NoLower).String(repoOwner)
-			} else {
-				return errors.New("unable to determine package publisher")
-			}
-
-			cleanSchemaFilePath := func(s string) string {
-				s = strings.ReplaceAll(s, "../", "")
-				s = strings.ReplaceAll(s, fmt.Sprintf("pulumi-%s", mainSpec.Name), "")
-				return s
-			}
-
-			pm := pkg.PackageMeta{
-				Name:        mainSpec.Name,
-				Description: mainSpec.Description,
-				LogoURL:     mainSpec.LogoURL,
-				Publisher:   publisherName,
-				Title:       title,
-
-				RepoURL:        mainSpec.Repository,
-				SchemaFilePath: cleanSchemaFilePath(schemaFile),
-
-				PackageStatus: status,
-				UpdatedOn:     publishedDate.Unix(),
-				Version:       version,
-
-				Category:  category,
-				Component: component,
-				Featured:  isFeaturedPackage(mainSpec.Name),
-				Native:    native,
-			}
-			b, err := yaml.Marshal(pm)
-			if err != nil {
-				return errors.Wrap(err, "generating package metadata")
-			}
-
 			if metadataDir == "" {
 				// if the user hasn't specified an metadataDir, we will default to
 				// the path within the registry folder.
 				metadataDir = "themes/default/data/registry/packages"
 			}
-			metadataFileName := fmt.Sprintf("%s.yaml", mainSpec.Name)
-			if err := pkg.EmitFile(metadataDir, metadataFileName, b); err != nil {
-				return errors.Wrap(err, "writing metadata file")
+
+			frontMatterRules := pkg.DefaultFrontMatterRules
+			if frontMatterRulesFile != "" {
+				frontMatterRules, err = pkg.LoadFrontMatterRules(frontMatterRulesFile)
+				if err != nil {
+					return err
+				}
 			}
 
-			if packageDocsDir == "" {
-				// if the user hasn't specified an packageDocsDir, we will default to
-				// the path within the registry folder.
-				packageDocsDir = fmt.Sprintf("themes/default/content/registry/packages/%s", mainSpec.Name)
+			opts := packageMetadataOptions{
+				repoSlug:           repoSlug,
+				repoOwner:          repoOwner,
+				version:            version,
+				publishedDate:      publishedDate,
+				title:              title,
+				component:          component,
+				publisher:          publisher,
+				categoryStr:        categoryStr,
+				statusStr:          statusStr,
+				deprecationMessage: deprecationMessage,
+				supersededBy:       supersededBy,
+				metadataDir:        metadataDir,
+				packageDocsDir:     packageDocsDir,
+				multiplePackages:   multiplePackages,
+				frontMatterRules:   frontMatterRules,
+				fixFrontMatter:     fixFrontMatter,
+				strictFrontMatter:  strictFrontMatter,
+				canonicalBaseURL:   canonicalBaseURL,
+				syncDocs:           syncDocs,
+				docsStaticDir:      docsStaticDir,
+				docsStaticURLPath:  docsStaticURLPath,
+				docsNavOutDir:      docsNavOutDir,
+				withChangelog:      withChangelog,
 			}
 
-			requiredFiles := []string{
-				"_index.md",
-				"installation-configuration.md",
+			schemaFiles := make([]string, 0, len(packages))
+			for _, p := range packages {
+				schemaFiles = append(schemaFiles, p.schemaFile)
 			}
-			for _, requiredFile := range requiredFiles {
-				requiredFilePath := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/docs/%s",
-					repoSlug, version, requiredFile)
-				details, err := readRemoteFile(requiredFilePath)
-				if err != nil {
+			progress := pkg.NewProgress("generating the metadata of", schemaFiles)
+			defer progress.ReportCanceled(ctx, os.Stderr)
+
+			for _, p := range packages {
+				if err := ctx.Err(); err != nil {
 					return err
 				}
+				if err := generatePackageMetadata(ctx, opts, p.schemaFile, p.manifest); err != nil {
+					return errors.Wrap(err, fmt.Sprintf("generating the metadata of %s", p.schemaFile))
+				}
+				progress.Done(p.schemaFile)
+			}
 
-				if err := pkg.EmitFile(packageDocsDir, requiredFile, details); err != nil {
-					return errors.Wrap(err, fmt.Sprintf("writing %s file", requiredFile))
+			if withChangelog {
+				// The recent updates live next to the metadata directory in the
+				// registry's data folder.
+				recentUpdatesFile := filepath.Join(filepath.Dir(filepath.Clean(metadataDir)), "recent_updates.yaml")
+				if err := pkg.WriteRecentUpdates(metadataDir, recentUpdatesFile, pkg.DefaultRecentUpdatesLimit); err != nil {
+					return errors.Wrap(err, "writing recent updates")
 				}
 			}
 
@@ -265,18 +222,50 @@ func PackageMetadataCmd() *cobra.Command {
 
 	cmd.Flags().StringVar(&repoSlug, "repoSlug", "", "The repository slug e.g. pulumi/pulumi-provider")
 	cmd.Flags().StringVar(&providerName, "providerName", "", "The name of the provider e.g. aws, aws-native. "+
-		"Required when there is no schemaFile flag specified.")
-	cmd.Flags().StringVarP(&schemaFile, "schemaFile", "s", "", "Relative path to the schema.json file from "+
+		"Used to infer the schemaFile when there is no schemaFile flag specified, in which case no plugin manifest is "+
+		"looked for.")
+	cmd.Flags().StringSliceVarP(&schemaFiles, "schemaFile", "s", nil, "Relative path to the schema.json file from "+
 		"the root of the repository. If no schemaFile is specified, then providerName is required so the schemaFile path can "+
-		"be inferred to be provider/cmd/pulumi-resource-<providerName>/schema.json")
+		"be inferred to be provider/cmd/pulumi-resource-<providerName>/schema.json. Can be specified multiple times for "+
+		"repositories that publish multiple packages, in which case the docs of each package are read from docs/<package>")
+	cmd.Flags().StringSliceVar(&pluginManifests, "pluginManifest", nil, "Relative path to the PulumiPlugin.yaml or "+
+		"pulumi-plugin.json of a package from the root of the repository. The schema is read from next to the "+
+		"manifest. Can be specified multiple times. If neither this nor schemaFile is specified, a plugin manifest "+
+		"at the root of the repository is used if there's one")
 	cmd.Flags().StringVar(&version, "version", "", "The version of the package")
 	cmd.Flags().StringVar(&categoryStr, "category", "", fmt.Sprintf("The category for the package. Value must "+
 		"match one of the keys in the map: %v", pkg.CategoryNameMap))
+	cmd.Flags().StringVar(&statusStr, "status", "", fmt.Sprintf("The lifecycle status of the package. Overrides the "+
+		"status determined from the schema keywords and the version. Value must match one of the keys in the map: %v",
+		pkg.PackageStatusNameMap))
+	cmd.Flags().StringVar(&deprecationMessage, "deprecationMessage", "", "An optional message to show to users of "+
+		"a deprecated package")
+	cmd.Flags().StringVar(&supersededBy, "supersededBy", "", "The name of the package that replaces a deprecated package")
 	cmd.Flags().StringVar(&publisher, "publisher", "", "The publisher's display name to be shown in the package. "+
 		"This will default to Pulumi")
 	cmd.Flags().StringVar(&title, "title", "", "The display name of the package. If omitted, the name of the "+
 		"package will be used")
 	cmd.Flags().BoolVar(&component, "component", false, "Whether or not this package is a component and not a provider")
+	cmd.Flags().BoolVar(&withChangelog, "withChangelog", false, "Generate the package's changelog.md from its "+
+		"GitHub releases and update the registry's recent updates")
+	cmd.Flags().StringVar(&frontMatterRulesFile, "frontMatterRules", "", "Path to a YAML file with the rules that "+
+		"the front matter of the package docs must follow, keyed by file name. Defaults to the rules the registry "+
+		"requires")
+	cmd.Flags().BoolVar(&fixFrontMatter, "fixFrontMatter", false, "Rewrite the front matter keys of the package "+
+		"docs that don't follow the rules and inject the missing ones")
+	cmd.Flags().BoolVar(&strictFrontMatter, "strictFrontMatter", false, "Fail instead of warning if the front "+
+		"matter of the package docs doesn't follow the rules")
+	cmd.Flags().StringVar(&canonicalBaseURL, "canonicalBaseURL", "", "If specified, the base URL of the registry "+
+		"used to inject the canonical_url of the package docs, e.g. https://www.pulumi.com")
+	cmd.Flags().BoolVar(&syncDocs, "syncDocs", false, "Mirror the entire docs directory of the repository at the "+
+		"version, including guides and the images they reference, instead of only the required files")
+	cmd.Flags().StringVar(&docsStaticDir, "docsStaticDir", "themes/default/static/registry/packages", "The "+
+		"directory to copy the images of the docs to when --syncDocs is set, under a sub-directory named after "+
+		"the package")
+	cmd.Flags().StringVar(&docsStaticURLPath, "docsStaticURLPath", "/registry/packages", "The URL path that "+
+		"docsStaticDir is served from, used to rewrite the image links of the docs")
+	cmd.Flags().StringVar(&docsNavOutDir, "docsNavOutDir", "themes/default/static/registry/packages/navs", "The "+
+		"directory to write the nav tree of the guides to when --syncDocs is set, as guides/<package>.json")
 	cmd.Flags().StringVar(&metadataDir, "metadataDir", "", "The location to save the metadata - this will default to the folder "+
 		"structure that the registry expects (themes/default/data/registry/packages)")
 	cmd.Flags().StringVar(&packageDocsDir, "packageDocsDir", "", "The location to save the package docs - this will default to the folder "+
@@ -288,8 +277,389 @@ func PackageMetadataCmd() *cobra.Command {
 	return cmd
 }
 
-func readRemoteFile(url string) ([]byte, error) {
-	resp, err := http.Get(url)
+// packageMetadataOptions are the flags of the metadata command that apply to
+// each of the packages it generates the metadata of.
+type packageMetadataOptions struct {
+	repoSlug      string
+	repoOwner     string
+	version       string
+	publishedDate time.Time
+
+	title              string
+	component          bool
+	publisher          string
+	categoryStr        string
+	statusStr          string
+	deprecationMessage string
+	supersededBy       string
+
+	metadataDir      string
+	packageDocsDir   string
+	multiplePackages bool
+
+	frontMatterRules  pkg.FrontMatterRules
+	fixFrontMatter    bool
+	strictFrontMatter bool
+	canonicalBaseURL  string
+
+	syncDocs          bool
+	docsStaticDir     string
+	docsStaticURLPath string
+	docsNavOutDir     string
+
+	withChangelog bool
+}
+
+// generatePackageMetadata writes the metadata and the docs of the package
+// whose schema is at schemaFile in the repo. manifest is the plugin manifest
+// that the schema was found from, if any.
+func generatePackageMetadata(ctx context.Context, opts packageMetadataOptions, schemaFile string,
+	manifest *pkg.PluginManifest) error {
+	// The flags that are derived from the schema when omitted are
+	// copied so that they are derived for each package.
+	title := opts.title
+	component := opts.component
+	packageDocsDir := opts.packageDocsDir
+
+	// we should be able to take the repo URL + the version + the schema url and
+	// construct a file that we can download and read
+	schemaFilePath := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s",
+		opts.repoSlug, opts.version, schemaFile)
+
+	schema, err := readRemoteFile(ctx, schemaFilePath)
+	if err != nil {
+		return err
+	}
+
+	if schema == nil {
+		return fmt.Errorf("unable to get contents of schemaFile %q", schemaFilePath)
+	}
+
+	// The source schema can be in YAML format. If that's the case
+	// convert it to JSON first.
+	if strings.HasSuffix(schemaFile, ".yaml") {
+		schema, err = yaml.YAMLToJSON(schema)
+		if err != nil {
+			return errors.Wrap(err, "reading YAML schema")
+		}
+	}
+
+	spec := &pschema.PackageSpec{}
+	if err := json.Unmarshal(schema, spec); err != nil {
+		return errors.Wrap(err, "unmarshalling schema into a PackageSpec")
+	}
+	spec.Version = opts.version
+
+	pluginDownloadURL := spec.PluginDownloadURL
+	var pluginRuntime string
+	if manifest != nil {
+		warnOnPluginManifestMismatch(manifest, spec.Name, opts.version)
+		if manifest.Server != "" {
+			pluginDownloadURL = manifest.Server
+		}
+		pluginRuntime = manifest.Runtime
+	}
+
+	if spec.Repository == "" {
+		// we already know the repo slug so we can reconstruct the repository name using that
+		spec.Repository = fmt.Sprintf("https://github.com/%s", opts.repoSlug)
+	}
+
+	status, err := getPackageStatus(spec, opts.version, opts.statusStr)
+	if err != nil {
+		return errors.Wrap(err, "getting status")
+	}
+
+	category, err := getPackageCategory(spec, opts.categoryStr)
+	if err != nil {
+		return errors.Wrap(err, "getting category")
+	}
+
+	// If the title was not overridden, then try to determine
+	// the title from the schema.
+	if title == "" {
+		// If the schema for this package does not have the
+		// displayName, then use its package name.
+		if spec.DisplayName == "" {
+			title = spec.Name
+			// Eventually all of Pulumi's own packages will have the displayName
+			// set in their schema but for the time being until they are updated
+			// with that info, let's lookup the proper title from the lookup map.
+			if v, ok := pkg.TitleLookup[spec.Name]; ok {
+				title = v
+			}
+		} else {
+			title = spec.DisplayName
+		}
+	}
+
+	native := spec.Attribution == ""
+	// If native is false, check if the schema has the "kind/native" tag in the Keywords
+	// array.
+	if !native {
+		native = isNative(spec.Keywords)
+	}
+
+	if !component {
+		component = isComponent(spec.Keywords)
+	}
+
+	if native && component {
+		native = false
+	}
+
+	// if there's a publisher then we need to use that immediately
+	// if there is no publisher on cmd, then try and use packageSpec
+	// if there's no publisher or packageSpec publisher, then assume repo owner is the publisher
+	// otherwise error
+	publisherName := ""
+	if opts.publisher != "" {
+		publisherName = opts.publisher
+	} else if opts.publisher == "" && spec.Publisher != "" {
+		publisherName = spec.Publisher
+	} else if opts.publisher == "" && opts.repoOwner != "" {
+		publisherName = cases.Title(language.Und, cases.NoLower).String(opts.repoOwner)
+	} else {
+		return errors.New("unable to determine package publisher")
+	}
+
+	cleanSchemaFilePath := func(s string) string {
+		s = strings.ReplaceAll(s, "../", "")
+		s = strings.ReplaceAll(s, fmt.Sprintf("pulumi-%s", spec.Name), "")
+		return s
+	}
+
+	metadataFileName := fmt.Sprintf("%s.yaml", spec.Name)
+
+	versions, err := getPublishedVersions(filepath.Join(opts.metadataDir, metadataFileName), opts.version)
+	if err != nil {
+		return errors.Wrap(err, "getting published versions")
+	}
+
+	pm := pkg.PackageMeta{
+		Name:        spec.Name,
+		Description: spec.Description,
+		LogoURL:     spec.LogoURL,
+		Publisher:   publisherName,
+		Title:       title,
+
+		RepoURL:        spec.Repository,
+		SchemaFilePath: cleanSchemaFilePath(schemaFile),
+
+		PackageStatus: status,
+		UpdatedOn:     opts.publishedDate.Unix(),
+		Version:       opts.version,
+
+		DeprecationMessage: opts.deprecationMessage,
+		SupersededBy:       opts.supersededBy,
+		Versions:           versions,
+
+		Category:  category,
+		Component: component,
+		Featured:  isFeaturedPackage(spec.Name),
+		Native:    native,
+
+		PluginRuntime:     pluginRuntime,
+		PluginDownloadURL: pluginDownloadURL,
+	}
+	b, err := yaml.Marshal(pm)
+	if err != nil {
+		return errors.Wrap(err, "generating package metadata")
+	}
+
+	if err := pkg.EmitFile(opts.metadataDir, metadataFileName, b); err != nil {
+		return errors.Wrap(err, "writing metadata file")
+	}
+
+	if packageDocsDir == "" {
+		// if the user hasn't specified an packageDocsDir, we will default to
+		// the path within the registry folder.
+		packageDocsDir = fmt.Sprintf("themes/default/content/registry/packages/%s", spec.Name)
+	} else if opts.multiplePackages {
+		packageDocsDir = filepath.Join(packageDocsDir, spec.Name)
+	}
+
+	docsPath := "docs"
+	if opts.multiplePackages {
+		docsPath = path.Join(docsPath, spec.Name)
+	}
+
+	var docsSync *pkg.DocsSync
+	if opts.syncDocs {
+		docsSync, err = pkg.NewDocsSync(ctx, pkg.SyncDocsOptions{
+			RepoSlug:      opts.repoSlug,
+			Ref:           opts.version,
+			PkgName:       spec.Name,
+			DocsDir:       docsPath,
+			DocsOutDir:    packageDocsDir,
+			StaticOutDir:  opts.docsStaticDir,
+			StaticURLPath: opts.docsStaticURLPath,
+			NavOutDir:     opts.docsNavOutDir,
+		})
+		if err != nil {
+			return errors.Wrap(err, "listing the docs directory")
+		}
+	}
+
+	var frontMatterProblems []string
+	requiredFiles := []string{
+		"_index.md",
+		"installation-configuration.md",
+	}
+	// fallbackGenerators generate the required files from the schema
+	// if the repository doesn't have them.
+	fallbackGenerators := map[string]func(*pschema.PackageSpec, string) ([]byte, error){
+		"_index.md":                     pkg.GenerateLandingPage,
+		"installation-configuration.md": pkg.GenerateInstallationConfiguration,
+	}
+	for _, requiredFile := range requiredFiles {
+		requiredFilePath := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s",
+			opts.repoSlug, opts.version, docsPath, requiredFile)
+		details, err := readRemoteFile(ctx, requiredFilePath)
+		if err != nil {
+			return err
+		}
+
+		if details == nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s was not found, generating %s from the schema instead\n",
+				requiredFilePath, requiredFile)
+			details, err = fallbackGenerators[requiredFile](spec, title)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("generating %s file", requiredFile))
+			}
+		}
+
+		if docsSync != nil {
+			details = docsSync.RewriteImageLinks(requiredFile, details)
+		}
+
+		frontMatterOpts := pkg.FrontMatterOptions{
+			Rule:         opts.frontMatterRules[requiredFile],
+			PackageTitle: title,
+			Description:  spec.Description,
+			Fix:          opts.fixFrontMatter,
+		}
+		if opts.canonicalBaseURL != "" {
+			frontMatterOpts.CanonicalURL = pkg.PackageDocsPageURL(opts.canonicalBaseURL, spec.Name, requiredFile)
+		}
+		details, problems := pkg.NormalizeFrontMatter(path.Join(docsPath, requiredFile), details, frontMatterOpts)
+		for _, p := range problems {
+			frontMatterProblems = append(frontMatterProblems, p.String())
+		}
+
+		if err := pkg.EmitFile(packageDocsDir, requiredFile, details); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("writing %s file", requiredFile))
+		}
+	}
+
+	if docsSync != nil {
+		// The required files are written above since they may need to
+		// be generated or fixed.
+		if err := docsSync.SyncPages(ctx, requiredFiles...); err != nil {
+			return errors.Wrap(err, "syncing the docs directory")
+		}
+		if err := docsSync.CopyImages(ctx); err != nil {
+			return errors.Wrap(err, "copying the docs images")
+		}
+	}
+
+	if len(frontMatterProblems) > 0 {
+		message := fmt.Sprintf("invalid front matter in the docs of %s:\n  %s", opts.repoSlug,
+			strings.Join(frontMatterProblems, "\n  "))
+		if opts.strictFrontMatter {
+			return errors.New(message)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", message)
+	}
+
+	if opts.withChangelog {
+		err := pkg.GenerateChangelog(ctx, pkg.ChangelogOptions{
+			RepoSlug:     opts.repoSlug,
+			PackageTitle: title,
+			ToVersion:    opts.version,
+			OutDir:       packageDocsDir,
+		})
+		if err != nil {
+			return errors.Wrap(err, "generating changelog")
+		}
+	}
+
+	return nil
+}
+
+// packageSource is where the schema of a package is read from, along with the
+// plugin manifest that it was discovered from, if any.
+type packageSource struct {
+	schemaFile string
+	manifest   *pkg.PluginManifest
+}
+
+// getPluginManifests fetches the plugin manifests at the given paths or, if
+// none are given, the plugin manifest at the root of the repo if it has one.
+func getPluginManifests(ctx context.Context, repoSlug, version string, manifestPaths []string) ([]*pkg.PluginManifest, error) {
+	if len(manifestPaths) == 0 {
+		m, err := pkg.FindPluginManifest(ctx, repoSlug, version)
+		if err != nil {
+			return nil, errors.Wrap(err, "looking for a plugin manifest")
+		}
+		if m == nil {
+			return nil, nil
+		}
+		glog.V(2).Infof("Using the plugin manifest %s\n", m.Path)
+		return []*pkg.PluginManifest{m}, nil
+	}
+
+	var manifests []*pkg.PluginManifest
+	for _, p := range manifestPaths {
+		m, err := pkg.FetchPluginManifest(ctx, repoSlug, version, p)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("reading the plugin manifest %s", p))
+		}
+		if m == nil {
+			return nil, errors.New(fmt.Sprintf("plugin manifest %s was not found in %s@%s", p, repoSlug, version))
+		}
+		manifests = append(manifests, m)
+	}
+	return manifests, nil
+}
+
+// warnOnPluginManifestMismatch warns if the name or version in a plugin
+// manifest doesn't match the package's schema or the version being published.
+// Versions that are substituted at build time, e.g. ${VERSION}, are ignored.
+func warnOnPluginManifestMismatch(m *pkg.PluginManifest, name, version string) {
+	if m.Name != "" && m.Name != name {
+		fmt.Fprintf(os.Stderr, "Warning: the name %q in %s doesn't match the name %q in the schema\n",
+			m.Name, m.Path, name)
+	}
+	if m.Version != "" && !strings.Contains(m.Version, "$") &&
+		strings.TrimPrefix(m.Version, "v") != strings.TrimPrefix(version, "v") {
+		fmt.Fprintf(os.Stderr, "Warning: the version %q in %s doesn't match the version %q\n",
+			m.Version, m.Path, version)
+	}
+}
+
+// getPublishedVersions adds version to the list of versions in the existing
+// metadata file of the package, if any, keeping the most recent version of
+// each major version.
+func getPublishedVersions(metadataFilePath string, version string) ([]string, error) {
+	b, err := os.ReadFile(metadataFilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return pkg.MergeVersions(nil, version)
+		}
+		return nil, errors.Wrap(err, fmt.Sprintf("reading the metadata file %s", metadataFilePath))
+	}
+
+	var existing pkg.PackageMeta
+	if err := yaml.Unmarshal(b, &existing); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("unmarshalling the metadata file %s", metadataFilePath))
+	}
+
+	return pkg.MergeVersions(append(existing.Versions, existing.Version), version)
+}
+
+func readRemoteFile(ctx context.Context, url string) ([]byte, error) {
+	resp, err := pkg.HTTPGet(ctx, url)
 	if err != nil {
 		return nil, errors.Wrap(err, fmt.Sprintf("downloading remote file from %s", url))
 	}
@@ -340,6 +710,20 @@ func getPackageCategory(mainSpec *pschema.PackageSpec, categoryOverrideStr strin
 	return category, nil
 }
 
+func getPackageStatus(mainSpec *pschema.PackageSpec, version, statusOverrideStr string) (pkg.PackageStatus, error) {
+	// If a status override was passed-in, use that instead of what's derived from the schema.
+	if statusOverrideStr != "" {
+		glog.V(2).Infof("Using status override name %s\n", statusOverrideStr)
+		status, ok := pkg.PackageStatusNameMap[statusOverrideStr]
+		if !ok {
+			return "", errors.New(fmt.Sprintf("invalid override for status name %s", statusOverrideStr))
+		}
+		return status, nil
+	}
+
+	return pkg.GetPackageStatus(mainSpec.Keywords, version)
+}
+
 // getCategoryFromKeywords searches for a tag in the provided keywords slice
 // with a prefix of category/. Returns the converted category type if such a tag
 // is found. Otherwise, returns PackageCategoryCloud always as the default.
@@ -399,9 +783,9 @@ func getTagFromKeywords(keywords []string, tag string) *string {
 	return nil
 }
 
-func getGitHubTags(repoSlug string) ([]pkg.GitHubTag, error) {
+func getGitHubTags(ctx context.Context, repoSlug string) ([]pkg.GitHubTag, error) {
 	path := fmt.Sprintf("/repos/%s/tags", repoSlug)
-	tagsResp, err := pkg.GetGitHubAPI(path)
+	tagsResp, err := pkg.GetGitHubAPI(ctx, path)
 	if err != nil {
 		return nil, errors.Wrap(err, fmt.Sprintf("getting tags info for %s", repoSlug))
 	}
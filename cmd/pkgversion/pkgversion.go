@@ -1,7 +1,7 @@
 package pkgversion
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 
 	"github.com/ghodss/yaml"
@@ -10,7 +10,6 @@ import (
 	"github.com/spf13/cobra"
 
 	"io/ioutil"
-	"net/http"
 	"strings"
 )
 
@@ -28,6 +27,7 @@ The version in the registry is defined in the YAML file at:
 
     https://raw.githubusercontent.com/pulumi/registry/master/themes/default/data/registry/packages/${PKG#pulumi/pulumi-}.yaml`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
 
 			if strings.Contains(repoSlug, "https") || strings.Contains(repoSlug, "github.com") {
 				return errors.New(fmt.Sprintf("Expected repoSlug to be in the format of `owner/repo`"+
@@ -40,14 +40,14 @@ The version in the registry is defined in the YAML file at:
 				repoName = githubSlugParts[1]
 			}
 
-			version, err := getLatestVersion(repoSlug)
+			version, err := pkg.GetLatestVersion(ctx, repoSlug)
 			if err != nil {
 				return err
 			}
 
 			pkgName := strings.TrimPrefix(repoName, "pulumi-")
 			pkgMetadata := fmt.Sprintf("https://raw.githubusercontent.com/pulumi/registry/master/themes/default/data/registry/packages/%s.yaml", pkgName)
-			regVersion, err := getRegistryVersion(pkgMetadata)
+			regVersion, err := getRegistryVersion(ctx, pkgMetadata)
 			if err != nil {
 				return err
 			}
@@ -67,31 +67,8 @@ The version in the registry is defined in the YAML file at:
 	return cmd
 }
 
-func getLatestVersion(repoSlug string) (string, error) {
-	path := fmt.Sprintf("/repos/%s/releases/latest", repoSlug)
-	resp, err := pkg.GetGitHubAPI(path)
-
-	if err != nil {
-		return "", errors.Wrap(err, fmt.Sprintf("getting latest version from https://api.github.com%s", path))
-	}
-
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return "", errors.Wrap(err, fmt.Sprintf("Could not find a release at https://api.github.com%s", path))
-	}
-
-	var tag pkg.GitHubTag
-	err = json.NewDecoder(resp.Body).Decode(&tag)
-
-	if err != nil {
-		return "", errors.Wrap(err, "failure reading contents of latest tag")
-	}
-
-	return tag.Name, nil
-}
-
-func getRegistryVersion(url string) (string, error) {
-	resp, err := http.Get(url)
+func getRegistryVersion(ctx context.Context, url string) (string, error) {
+	resp, err := pkg.HTTPGet(ctx, url)
 	if err != nil {
 		return "", errors.Wrap(err, fmt.Sprintf("getting latest version from %s", url))
 	}
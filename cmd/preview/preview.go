@@ -0,0 +1,80 @@
+package preview
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/registrygen/pkg"
+	"github.com/spf13/cobra"
+)
+
+func Command() *cobra.Command {
+	var schemaFile string
+	var overlaySchemaFile string
+	var docsDir string
+	var addr string
+	var registryPackagesPath string
+	var schemaCacheDir string
+
+	cmd := &cobra.Command{
+		Use:   "preview",
+		Short: "Preview the docs of a local schema",
+		Long: "Serve the API docs of a local schema, and optionally its package docs, rendered to HTML with " +
+			"the navigation of the package tree. The docs are regenerated whenever the schema, the overlay schema " +
+			"or the package docs change and the open pages are reloaded.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			loader, err := pkg.NewSchemaLoader(ctx, pkg.SchemaLoaderOptions{
+				RegistryPackagesPath: registryPackagesPath,
+				CacheDir:             schemaCacheDir,
+			})
+			if err != nil {
+				return err
+			}
+
+			server := pkg.NewPreviewServer(pkg.PreviewOptions{
+				SchemaFile:        schemaFile,
+				OverlaySchemaFile: overlaySchemaFile,
+				DocsDir:           docsDir,
+				SchemaLoader:      loader,
+			})
+
+			// A schema that doesn't generate is reported in the preview so
+			// that it can be fixed while the server is running.
+			if err := server.Generate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error generating the docs: %v\n", err)
+			}
+			go server.Watch(ctx.Done(), os.Stdout)
+
+			// The server is shut down once the command is interrupted.
+			httpServer := &http.Server{Addr: addr, Handler: server}
+			go func() {
+				<-ctx.Done()
+				httpServer.Close()
+			}()
+
+			fmt.Printf("Serving the preview at http://%s/\n", addr)
+			if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+				return errors.Wrap(err, "serving the preview")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&schemaFile, "schemaFile", "s", "", "Path to the schema file")
+	cmd.Flags().StringVar(&overlaySchemaFile, "overlaySchemaFile", "", "Path to an overlay schema file whose "+
+		"resources, types and language info are merged into the schema")
+	cmd.Flags().StringVar(&docsDir, "docsDir", "", "The directory of the package docs, e.g. the docs directory of "+
+		"the repository, served at the root of the preview")
+	cmd.Flags().StringVar(&addr, "addr", "localhost:1313", "The address to serve the preview on")
+	cmd.Flags().StringVar(&registryPackagesPath, "registryPackagesPath", "", "The path to the registry metadata "+
+		"files, used to find the repositories of the packages referenced by the schema")
+	cmd.Flags().StringVar(&schemaCacheDir, "schemaCacheDir", pkg.DefaultSchemaCacheDir(), "The directory to "+
+		"cache the schemas of the packages referenced by the schema in")
+
+	cmd.MarkFlagRequired("schemaFile")
+
+	return cmd
+}
@@ -0,0 +1,84 @@
+package publish
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/pulumi/registrygen/pkg"
+	"github.com/spf13/cobra"
+)
+
+func Command() *cobra.Command {
+	var endpoint string
+	var metadataFile string
+	var docsDir string
+	var navFile string
+	var dryRun bool
+	var retries int
+
+	cmd := &cobra.Command{
+		Use:   "publish",
+		Short: "Publish the metadata and docs of a package to a registry API",
+		Long: "Upload the metadata of a package version, its generated API docs and its nav tree to a registry " +
+			"API instead of a Hugo repository. The API token is read from the REGISTRY_API_TOKEN environment " +
+			"variable.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			b, err := os.ReadFile(metadataFile)
+			if err != nil {
+				return errors.Wrap(err, "reading the metadata file")
+			}
+			var meta pkg.PackageMeta
+			if err := yaml.Unmarshal(b, &meta); err != nil {
+				return errors.Wrap(err, fmt.Sprintf("unmarshalling the metadata file %s", metadataFile))
+			}
+
+			bundle := pkg.PublishBundle{Metadata: meta}
+			if docsDir != "" {
+				bundle.Files, err = pkg.ReadFileMap(docsDir)
+				if err != nil {
+					return err
+				}
+			}
+			if navFile != "" {
+				bundle.NavTree, err = os.ReadFile(navFile)
+				if err != nil {
+					return errors.Wrap(err, "reading the nav tree")
+				}
+			}
+
+			opts := pkg.PublishOptions{
+				Endpoint: endpoint,
+				Token:    os.Getenv("REGISTRY_API_TOKEN"),
+				DryRun:   dryRun,
+				Retries:  retries,
+			}
+			if err := pkg.Publish(ctx, opts, bundle); err != nil {
+				return errors.Wrap(err, "publishing")
+			}
+
+			if dryRun {
+				fmt.Printf("Dry run of publishing %s@%s with %d docs files\n", meta.Name, meta.Version, len(bundle.Files))
+				return nil
+			}
+			fmt.Printf("Published %s@%s with %d docs files\n", meta.Name, meta.Version, len(bundle.Files))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&endpoint, "endpoint", "", "The base URL of the registry API, e.g. https://registry.example.com/api")
+	cmd.Flags().StringVar(&metadataFile, "metadataFile", "", "Path to the metadata file of the package generated "+
+		"by the metadata command")
+	cmd.Flags().StringVar(&docsDir, "docsDir", "", "The directory of the generated API docs of the package")
+	cmd.Flags().StringVar(&navFile, "navFile", "", "Path to the package tree JSON file of the API docs")
+	cmd.Flags().BoolVar(&dryRun, "dryRun", false, "Print the requests that would be made instead of making them")
+	cmd.Flags().IntVar(&retries, "retries", 3, "The number of times a request is retried after a network error, "+
+		"a 429 or a 5xx response")
+
+	cmd.MarkFlagRequired("endpoint")
+	cmd.MarkFlagRequired("metadataFile")
+
+	return cmd
+}
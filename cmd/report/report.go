@@ -0,0 +1,116 @@
+package report
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"github.com/pulumi/registrygen/pkg"
+	"github.com/spf13/cobra"
+)
+
+func Command() *cobra.Command {
+	reportCommand := &cobra.Command{
+		Use:   "report",
+		Short: "Generate reports about the packages in a registry",
+	}
+
+	reportCommand.AddCommand(ExamplesCmd())
+
+	return reportCommand
+}
+
+func ExamplesCmd() *cobra.Command {
+	var schemaFile string
+	var repoSlug string
+	var version string
+	var registryPackagesPath string
+	var outDir string
+	var format string
+	var schemaCacheDir string
+
+	cmd := &cobra.Command{
+		Use:   "examples",
+		Short: "Generate a report of the example coverage of packages",
+		Long: "Generate a matrix of the number of examples of each resource and function by language, with " +
+			"the totals per module. The report covers either a single schema, or every package in the " +
+			"registry if registryPackagesPath is specified.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if format != "markdown" && format != "json" {
+				return errors.New(fmt.Sprintf("unsupported format %q, must be one of markdown or json", format))
+			}
+
+			loader, err := pkg.NewSchemaLoader(ctx, pkg.SchemaLoaderOptions{
+				RegistryPackagesPath: registryPackagesPath,
+				CacheDir:             schemaCacheDir,
+			})
+			if err != nil {
+				return errors.Wrap(err, "creating the schema loader")
+			}
+
+			report := &pkg.ExampleCoverageReport{}
+			switch {
+			case registryPackagesPath != "":
+				packages, err := pkg.LoadPackageMetadata(registryPackagesPath)
+				if err != nil {
+					return err
+				}
+				for _, p := range packages {
+					glog.V(2).Infof("Computing the example coverage of %s@%s", p.Name, p.Version)
+					pulPkg, err := pkg.LoadPackage(ctx, p.SchemaFilePath, p.RepoURL, p.Version, loader)
+					if err != nil {
+						return errors.Wrapf(err, "loading schema of %s", p.Name)
+					}
+					report.Packages = append(report.Packages, pkg.GetExampleCoverage(pulPkg))
+				}
+			case schemaFile != "":
+				if repoSlug != "" && version == "" {
+					return errors.New("version is required when repoSlug is specified")
+				}
+				pulPkg, err := pkg.LoadPackage(ctx, schemaFile, repoSlug, version, loader)
+				if err != nil {
+					return errors.Wrap(err, "loading schema")
+				}
+				report.Packages = append(report.Packages, pkg.GetExampleCoverage(pulPkg))
+			default:
+				return errors.New("one of schemaFile or registryPackagesPath is required")
+			}
+
+			if format == "json" {
+				b, err := report.JSON()
+				if err != nil {
+					return errors.Wrap(err, "marshalling report")
+				}
+				return writeReport(outDir, "example-coverage.json", b)
+			}
+			return writeReport(outDir, "example-coverage.md", report.Markdown())
+		},
+	}
+
+	cmd.Flags().StringVarP(&schemaFile, "schemaFile", "s", "", "Path to the schema.json file. If repoSlug is "+
+		"specified, the path is relative to the root of the repository")
+	cmd.Flags().StringVar(&repoSlug, "repoSlug", "", "The repository slug e.g. pulumi/pulumi-provider. If omitted, "+
+		"schemaFile is read from the local filesystem")
+	cmd.Flags().StringVar(&version, "version", "", "The version of the package. Required with repoSlug")
+	cmd.Flags().StringVar(&registryPackagesPath, "registryPackagesPath", "", "The path to the registry metadata "+
+		"files. If specified, the report covers every package in the registry")
+	cmd.Flags().StringVar(&outDir, "outDir", "", "The directory path to write the report to. If omitted, the "+
+		"report is printed to stdout")
+	cmd.Flags().StringVar(&format, "format", "markdown", "The format of the report, one of markdown or json")
+	cmd.Flags().StringVar(&schemaCacheDir, "schemaCacheDir", pkg.DefaultSchemaCacheDir(), "The directory to cache the schemas of the "+
+		"packages referenced by the schema in")
+
+	return cmd
+}
+
+func writeReport(outDir, fileName string, b []byte) error {
+	if outDir == "" {
+		fmt.Println(string(b))
+		return nil
+	}
+	if err := pkg.EmitFile(outDir, fileName, b); err != nil {
+		return errors.Wrapf(err, "writing %s", fileName)
+	}
+	return nil
+}
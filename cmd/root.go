@@ -1,26 +1,70 @@
 package cmd
 
 import (
+	"context"
+	"time"
+
+	"github.com/pulumi/registrygen/cmd/bump"
+	"github.com/pulumi/registrygen/cmd/bundle"
+	"github.com/pulumi/registrygen/cmd/catalog"
+	"github.com/pulumi/registrygen/cmd/changelog"
+	"github.com/pulumi/registrygen/cmd/check"
 	"github.com/pulumi/registrygen/cmd/docs"
+	"github.com/pulumi/registrygen/cmd/examples"
 	"github.com/pulumi/registrygen/cmd/metadata"
 	"github.com/pulumi/registrygen/cmd/pkgversion"
+	"github.com/pulumi/registrygen/cmd/preview"
+	"github.com/pulumi/registrygen/cmd/publish"
+	"github.com/pulumi/registrygen/cmd/report"
 	"github.com/pulumi/registrygen/cmd/version"
+	"github.com/pulumi/registrygen/pkg"
 	"github.com/spf13/cobra"
 )
 
 func RootCmd() *cobra.Command {
+	var timeout time.Duration
+	var requestTimeout time.Duration
+	var cancelTimeout context.CancelFunc
+
 	rootCmd := &cobra.Command{
 		Use:   "registrygen",
 		Short: "Generate Package Metadata and API Docs for the Pulumi registry",
 		Long: "A tool to generate API docs and package metadata for Pulumi packages. " +
 			"This tool relies on a Pulumi package's schema spec. " +
 			"This tool will not generate the schema.",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			pkg.RequestTimeout = requestTimeout
+			if timeout > 0 {
+				ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+				cancelTimeout = cancel
+				cmd.SetContext(ctx)
+			}
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			if cancelTimeout != nil {
+				cancelTimeout()
+			}
+		},
 	}
 
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "The maximum duration of the command, e.g. 30m, "+
+		"after which it stops like when it's interrupted. 0 means no timeout")
+	rootCmd.PersistentFlags().DurationVar(&requestTimeout, "requestTimeout", pkg.DefaultRequestTimeout, "The "+
+		"timeout of each request to GitHub and the registry, including downloading the response. 0 means no timeout")
+
 	rootCmd.AddCommand(metadata.PackageMetadataCmd())
 	rootCmd.AddCommand(version.Command())
 	rootCmd.AddCommand(docs.GenerateCommand())
 	rootCmd.AddCommand(pkgversion.CheckVersion())
+	rootCmd.AddCommand(changelog.Command())
+	rootCmd.AddCommand(catalog.Command())
+	rootCmd.AddCommand(examples.Command())
+	rootCmd.AddCommand(report.Command())
+	rootCmd.AddCommand(check.Command())
+	rootCmd.AddCommand(publish.Command())
+	rootCmd.AddCommand(bump.Command())
+	rootCmd.AddCommand(preview.Command())
+	rootCmd.AddCommand(bundle.ImportCommand())
 
 	return rootCmd
 }
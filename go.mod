@@ -3,9 +3,12 @@ module github.com/pulumi/registrygen
 go 1.16
 
 require (
+	github.com/blang/semver v3.5.1+incompatible
 	github.com/ghodss/yaml v1.0.0
 	github.com/golang/glog v1.0.0
+	github.com/pgavlin/goldmark v1.1.33-0.20200616210433-b5eb04559386
 	github.com/pkg/errors v0.9.1
+	github.com/pulumi/pulumi-java/pkg v0.7.1
 	github.com/pulumi/pulumi/pkg/v3 v3.53.0
 	github.com/pulumi/pulumi/sdk/v3 v3.53.0
 	github.com/spf13/cobra v1.6.1
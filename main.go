@@ -15,8 +15,12 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/golang/glog"
 	"github.com/pulumi/registrygen/cmd"
@@ -27,8 +31,18 @@ func main() {
 
 	defer glog.Flush()
 
-	if err := cmd.RootCmd().Execute(); err != nil {
+	// The first SIGINT or SIGTERM cancels the context so that the command
+	// stops after cleaning up its staging output. A second one kills it.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+		fmt.Fprintln(os.Stderr, "Interrupted, stopping. Interrupt again to exit immediately.")
+	}()
+
+	if err := cmd.RootCmd().ExecuteContext(ctx); err != nil {
 		glog.Errorf("Failed to execute command: %v", err)
+		glog.Flush()
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,324 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	docsgen "github.com/pulumi/pulumi/pkg/v3/codegen/docs"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+const (
+	// DocsFormatDocusaurus is MDX for a Docusaurus site.
+	DocsFormatDocusaurus DocsFormat = "docusaurus"
+	// DocsFormatMkDocs is Markdown for an MkDocs site using the Material
+	// theme's content tabs.
+	DocsFormatMkDocs DocsFormat = "mkdocs"
+)
+
+// OutputAdapter writes the API docs generated for the registry in the format
+// of another docs site.
+type OutputAdapter interface {
+	// ConvertPackage returns the docs of a package that are written to
+	// outDir, keyed by their path relative to outDir. The files are the
+	// Markdown pages generated for the registry keyed by the same paths, and
+	// nav is their package tree.
+	ConvertPackage(meta PackageMeta, outDir string, files map[string][]byte,
+		nav []docsgen.PackageTreeItem) (map[string][]byte, error)
+	// Write writes the files that tie together the packages written so far,
+	// such as an index page or the navigation of the site, for the docs
+	// directory outDir.
+	Write(outDir string) error
+}
+
+var (
+	choosableTagRegexp = regexp.MustCompile(`<pulumi-choosable type="[^"]*" values="([^"]*)">|</pulumi-choosable>`)
+
+	// The wrappers of the choosers, choosables and examples in the generated
+	// pages, which are replaced by the tabs of the docs site.
+	choosableOpenWrapperRegexp  = regexp.MustCompile(`<div>\s*(<pulumi-choosable [^>]*>)`)
+	choosableCloseWrapperRegexp = regexp.MustCompile(`(</pulumi-choosable>)\s*</div>`)
+	chooserElementRegexp        = regexp.MustCompile(`(?:<div>\s*)?<pulumi-chooser [^>]*>\s*</pulumi-chooser>(?:\s*</div>)?`)
+	examplesElementRegexp       = regexp.MustCompile(`(?:<div>\s*)?<pulumi-examples>|</pulumi-examples>(?:\s*</div>)?`)
+
+	// highlightedCodeRegexp matches the code blocks that are rendered to HTML
+	// by the docs generator, which are converted back to fenced code blocks.
+	highlightedCodeRegexp = regexp.MustCompile(`(?s)<div class="highlight"><pre class="chroma"><code class="language-(\w+)" data-lang="\w+">(.*?)</code></pre></div>`)
+	htmlTagRegexp         = regexp.MustCompile(`<[^>]+>`)
+
+	// choosableLanguageOrder is the order of the tabs, which is the order of
+	// the options of the language choosers.
+	choosableLanguageOrder  = []string{"typescript", "python", "go", "csharp", "java", "yaml"}
+	choosableLanguageLabels = map[string]string{
+		"typescript": "TypeScript",
+		"python":     "Python",
+		"go":         "Go",
+		"csharp":     "C#",
+		"java":       "Java",
+		"yaml":       "YAML",
+	}
+)
+
+// docsTab is the content of a page for one language.
+type docsTab struct {
+	Language string
+	Label    string
+	Content  string
+}
+
+// docsNode is either a run of text or a choosable with the nodes it contains.
+type docsNode struct {
+	text      string
+	choosable bool
+	languages []string
+	children  []docsNode
+}
+
+// convertChoosables replaces the language choosers and choosables of a page
+// with the tabs rendered by tabs. Each run of adjacent choosables becomes one
+// set of tabs, and the choosables of several languages are unwrapped since
+// their content applies to all of the tabs.
+func convertChoosables(body string, tabs func([]docsTab) string) string {
+	body = convertShortcodes(body)
+	body = highlightedCodeRegexp.ReplaceAllStringFunc(body, func(block string) string {
+		m := highlightedCodeRegexp.FindStringSubmatch(block)
+		code := html.UnescapeString(htmlTagRegexp.ReplaceAllString(m[2], ""))
+		return fmt.Sprintf("\n\n```%s\n%s\n```\n\n", m[1], strings.TrimRight(code, "\n"))
+	})
+	body = chooserElementRegexp.ReplaceAllString(body, "")
+	body = examplesElementRegexp.ReplaceAllString(body, "")
+	body = choosableOpenWrapperRegexp.ReplaceAllString(body, "$1")
+	body = choosableCloseWrapperRegexp.ReplaceAllString(body, "$1")
+
+	return renderDocsNodes(parseChoosables(body), tabs)
+}
+
+// parseChoosables parses the choosables of a page, which can be nested.
+func parseChoosables(body string) []docsNode {
+	root := &docsNode{}
+	stack := []*docsNode{root}
+	last := 0
+	for _, m := range choosableTagRegexp.FindAllStringSubmatchIndex(body, -1) {
+		parent := stack[len(stack)-1]
+		parent.children = append(parent.children, docsNode{text: body[last:m[0]]})
+		last = m[1]
+
+		if m[2] >= 0 {
+			stack = append(stack, &docsNode{
+				choosable: true,
+				languages: strings.Split(body[m[2]:m[3]], ","),
+			})
+			continue
+		}
+		// Unbalanced closing tags are dropped.
+		if len(stack) > 1 {
+			node := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, *node)
+		}
+	}
+	stack[len(stack)-1].children = append(stack[len(stack)-1].children, docsNode{text: body[last:]})
+	// Unclosed choosables are closed at the end of the page.
+	for len(stack) > 1 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		stack[len(stack)-1].children = append(stack[len(stack)-1].children, *node)
+	}
+	return root.children
+}
+
+// choosableLanguage returns the language of the tab of a choosable, or false
+// if it applies to several languages.
+func choosableLanguage(languages []string) (string, bool) {
+	found := ""
+	for _, l := range languages {
+		switch l {
+		case "javascript", "nodejs":
+			l = "typescript"
+		}
+		if found != "" && found != l {
+			return "", false
+		}
+		found = l
+	}
+	return found, found != ""
+}
+
+func renderDocsNodes(nodes []docsNode, tabs func([]docsTab) string) string {
+	var sb strings.Builder
+	var run []docsTab
+	// pending is the whitespace between the choosables of a run, which is
+	// kept if the run ends.
+	pending := ""
+	flush := func() {
+		if len(run) > 0 {
+			sort.SliceStable(run, func(i, j int) bool {
+				return languageOrder(run[i].Language) < languageOrder(run[j].Language)
+			})
+			sb.WriteString("\n\n")
+			sb.WriteString(tabs(run))
+			sb.WriteString("\n\n")
+			run = nil
+		}
+		sb.WriteString(pending)
+		pending = ""
+	}
+
+	for _, node := range nodes {
+		if !node.choosable {
+			if len(run) > 0 && strings.TrimSpace(node.text) == "" {
+				pending += node.text
+				continue
+			}
+			flush()
+			sb.WriteString(node.text)
+			continue
+		}
+
+		content := renderDocsNodes(node.children, tabs)
+		language, ok := choosableLanguage(node.languages)
+		if !ok {
+			flush()
+			sb.WriteString(content)
+			continue
+		}
+		for _, tab := range run {
+			if tab.Language == language {
+				flush()
+				break
+			}
+		}
+		pending = ""
+		label := choosableLanguageLabels[language]
+		if label == "" {
+			label = language
+		}
+		run = append(run, docsTab{Language: language, Label: label, Content: strings.TrimSpace(content)})
+	}
+	flush()
+	return sb.String()
+}
+
+func languageOrder(language string) int {
+	for i, l := range choosableLanguageOrder {
+		if l == language {
+			return i
+		}
+	}
+	return len(choosableLanguageOrder)
+}
+
+// outputPackageMeta returns the metadata of a package passed to the output
+// adapters.
+func outputPackageMeta(spec *pschema.PackageSpec, version string, status PackageStatus) PackageMeta {
+	title := spec.DisplayName
+	if title == "" {
+		title = spec.Name
+		if v, ok := TitleLookup[spec.Name]; ok {
+			title = v
+		}
+	}
+	return PackageMeta{
+		Name:          spec.Name,
+		Title:         title,
+		Description:   spec.Description,
+		LogoURL:       spec.LogoURL,
+		RepoURL:       spec.Repository,
+		Publisher:     spec.Publisher,
+		PackageStatus: status,
+		Version:       version,
+	}
+}
+
+// relativeRoot returns the relative URL of the root of the docs from the page
+// of a file, whose URL is the directory of the file.
+func relativeRoot(file string) string {
+	dir := path.Dir(file)
+	if dir == "." {
+		return "./"
+	}
+	return strings.Repeat("../", strings.Count(dir, "/")+1)
+}
+
+// adapterPage is a page of the generated docs converted for another docs
+// site.
+type adapterPage struct {
+	Title       string
+	Description string
+	Body        string
+}
+
+// convertDocsPage converts a generated page for another docs site, rendering
+// its choosables with tabs. The links to the pages of the package are made
+// relative to the page.
+func convertDocsPage(pkgName, file string, contents []byte, tabs func([]docsTab) string) adapterPage {
+	contents = bytes.TrimLeft(contents, "\r\n")
+	page := adapterPage{Title: pageTitle(file, contents)}
+
+	body := string(contents)
+	if fm, _ := parseFrontMatter(body); fm != nil {
+		body = strings.Join(fm.lines[fm.end+1:], "\n")
+		if desc := fm.value("meta_desc"); desc != nil {
+			page.Description = fmt.Sprint(desc)
+		}
+	}
+
+	root := relativeRoot(file)
+	body = rewriteDocsLinks(body, pkgName, root, root)
+	page.Body = strings.TrimSpace(convertChoosables(body, tabs)) + "\n"
+	return page
+}
+
+// adapterPackage is a package written by an output adapter.
+type adapterPackage struct {
+	Meta   PackageMeta
+	OutDir string
+	Nav    []docsgen.PackageTreeItem
+}
+
+// docsDirPath returns the path of a package's docs directory relative to the
+// docs directory of the site, with a trailing slash unless it's the docs
+// directory itself.
+func (p adapterPackage) docsDirPath(docsDir string) (string, error) {
+	rel, err := filepath.Rel(docsDir, p.OutDir)
+	if err != nil {
+		return "", fmt.Errorf("getting the path of the docs of %s: %w", p.Meta.Name, err)
+	}
+	if rel == "." {
+		return "", nil
+	}
+	return filepath.ToSlash(rel) + "/", nil
+}
+
+// adapterNavItem is an item of the package tree with the path of its page
+// directory relative to the package's docs directory.
+type adapterNavItem struct {
+	Name     string
+	Dir      string
+	Children []adapterNavItem
+}
+
+// resolveNav resolves the links of the package tree, which are relative to
+// their parent.
+func resolveNav(items []docsgen.PackageTreeItem, parentDir string) []adapterNavItem {
+	var nav []adapterNavItem
+	for _, item := range items {
+		dir := parentDir + item.Link
+		if !strings.HasSuffix(dir, "/") {
+			dir += "/"
+		}
+		nav = append(nav, adapterNavItem{
+			Name:     item.Name,
+			Dir:      dir,
+			Children: resolveNav(item.Children, dir),
+		})
+	}
+	return nav
+}
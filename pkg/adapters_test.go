@@ -0,0 +1,179 @@
+package pkg
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	docsgen "github.com/pulumi/pulumi/pkg/v3/codegen/docs"
+)
+
+// testTabs renders the tabs as [language: content | ...] to check the runs
+// of choosables that become tabs.
+func testTabs(tabs []docsTab) string {
+	var parts []string
+	for _, tab := range tabs {
+		parts = append(parts, tab.Language+": "+tab.Content)
+	}
+	return "[" + strings.Join(parts, " | ") + "]"
+}
+
+func TestConvertChoosables(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name: "adjacent choosables become tabs in the order of the chooser",
+			body: `<div><pulumi-chooser type="language" options="typescript,python"></pulumi-chooser></div>
+<div><pulumi-choosable type="language" values="python">
+py
+</pulumi-choosable></div>
+<div><pulumi-choosable type="language" values="javascript,typescript">
+ts
+</pulumi-choosable></div>
+after`,
+			expected: "\n\n\n[typescript: ts | python: py]\n\n\nafter",
+		},
+		{
+			name: "choosables of several languages are unwrapped",
+			body: `before
+<pulumi-choosable type="language" values="python,go">
+shared
+</pulumi-choosable>
+after`,
+			expected: "before\n\nshared\n\nafter",
+		},
+		{
+			name: "a repeated language starts new tabs",
+			body: `<pulumi-choosable type="language" values="go">a</pulumi-choosable>` +
+				`<pulumi-choosable type="language" values="go">b</pulumi-choosable>`,
+			expected: "\n\n[go: a]\n\n\n\n[go: b]\n\n",
+		},
+		{
+			name: "nested choosables",
+			body: `<pulumi-choosable type="language" values="typescript,python">` +
+				`<pulumi-choosable type="language" values="typescript">ts</pulumi-choosable>` +
+				`<pulumi-choosable type="language" values="python">py</pulumi-choosable>` +
+				`</pulumi-choosable>`,
+			expected: "\n\n[typescript: ts | python: py]\n\n",
+		},
+		{
+			name:     "unbalanced closing tags are dropped",
+			body:     `text</pulumi-choosable> more`,
+			expected: "text more",
+		},
+		{
+			name:     "unclosed choosables are closed at the end of the page",
+			body:     `text <pulumi-choosable type="language" values="go">go`,
+			expected: "text \n\n[go: go]\n\n",
+		},
+		{
+			name: "highlighted code becomes a code fence",
+			body: `<div class="highlight"><pre class="chroma"><code class="language-go" data-lang="go">` +
+				`<span class="kd">func</span> <span class="nx">f</span>() &lt;-chan int
+</code></pre></div>`,
+			expected: "\n\n```go\nfunc f() <-chan int\n```\n\n",
+		},
+		{
+			name: "examples are unwrapped",
+			body: `<div><pulumi-examples>
+<pulumi-choosable type="language" values="go">ex</pulumi-choosable>
+</pulumi-examples></div>`,
+			expected: "\n\n\n[go: ex]\n\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := convertChoosables(tt.body, testTabs); actual != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseChoosables(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected []docsNode
+	}{
+		{
+			name:     "no choosables",
+			body:     "text",
+			expected: []docsNode{{text: "text"}},
+		},
+		{
+			name: "nested choosables",
+			body: `a<pulumi-choosable type="language" values="go,python">b` +
+				`<pulumi-choosable type="language" values="go">c</pulumi-choosable>` +
+				`</pulumi-choosable>d`,
+			expected: []docsNode{
+				{text: "a"},
+				{choosable: true, languages: []string{"go", "python"}, children: []docsNode{
+					{text: "b"},
+					{choosable: true, languages: []string{"go"}, children: []docsNode{{text: "c"}}},
+					{text: ""},
+				}},
+				{text: "d"},
+			},
+		},
+		{
+			name: "unbalanced closing tag",
+			body: `a</pulumi-choosable>b`,
+			expected: []docsNode{
+				{text: "a"},
+				{text: "b"},
+			},
+		},
+		{
+			name: "unclosed choosables",
+			body: `a<pulumi-choosable type="language" values="go">b` +
+				`<pulumi-choosable type="language" values="python">c`,
+			expected: []docsNode{
+				{text: "a"},
+				{choosable: true, languages: []string{"go"}, children: []docsNode{
+					{text: "b"},
+					{choosable: true, languages: []string{"python"}, children: []docsNode{{text: "c"}}},
+				}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := parseChoosables(tt.body); !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestResolveNav(t *testing.T) {
+	items := []docsgen.PackageTreeItem{
+		{
+			Name: "random",
+			Link: "random",
+			Children: []docsgen.PackageTreeItem{
+				{Name: "RandomId", Link: "randomid/"},
+				{Name: "RandomPet", Link: "randompet"},
+			},
+		},
+		{Name: "Provider", Link: "provider/"},
+	}
+	expected := []adapterNavItem{
+		{
+			Name: "random",
+			Dir:  "v4/random/",
+			Children: []adapterNavItem{
+				{Name: "RandomId", Dir: "v4/random/randomid/"},
+				{Name: "RandomPet", Dir: "v4/random/randompet/"},
+			},
+		},
+		{Name: "Provider", Dir: "v4/provider/"},
+	}
+
+	if actual := resolveNav(items, "v4/"); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+}
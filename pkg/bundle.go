@@ -0,0 +1,501 @@
+package pkg
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// bundleManifestFile is the manifest at the root of a bundle.
+	bundleManifestFile = "manifest.json"
+	// registryPackagesContentDir is the directory of the registry that holds
+	// the docs of the packages.
+	registryPackagesContentDir = "themes/default/content/registry/packages"
+	// registryPackagesDataDir holds the metadata of the packages.
+	registryPackagesDataDir = "themes/default/data/registry/packages"
+	// registryPackagesNavsDir holds the nav trees of the packages.
+	registryPackagesNavsDir = "themes/default/static/registry/packages/navs"
+)
+
+// BundleManifest lists the files of a bundle of the generated content of a
+// package version.
+type BundleManifest struct {
+	Package string `json:"package"`
+	Version string `json:"version"`
+	// ReplaceDirs are the directories whose existing files are removed when
+	// the bundle is imported, so that the pages that are no longer generated
+	// don't linger. Only the API docs directory of the package, as returned
+	// by PackageAPIDocsDir, can be replaced.
+	ReplaceDirs []string     `json:"replace_dirs,omitempty"`
+	Files       []BundleFile `json:"files"`
+}
+
+// BundleFile is a file of a bundle, with its path relative to the root of the
+// registry.
+type BundleFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// PackageAPIDocsDir returns the directory of the API docs of a package
+// relative to the root of the registry.
+func PackageAPIDocsDir(pkgName string) string {
+	return path.Join(registryPackagesContentDir, pkgName, "api-docs")
+}
+
+// isPackagePath returns whether a path, relative to the root of the registry,
+// belongs to a package: its metadata, its docs or its nav trees.
+func isPackagePath(pkgName, p string) bool {
+	switch p {
+	case path.Join(registryPackagesDataDir, pkgName+".yaml"), path.Join(registryPackagesNavsDir, pkgName+".json"):
+		return true
+	}
+	return strings.HasPrefix(p, path.Join(registryPackagesContentDir, pkgName)+"/") ||
+		strings.HasPrefix(p, path.Join(registryPackagesNavsDir, pkgName)+"/")
+}
+
+// WriteBundle writes the files at the given paths relative to rootDir, and
+// the files in them for directories, to a .tar.gz, .tgz or .zip archive along
+// with a manifest.json listing their paths, sizes and SHA-256s. The paths that
+// don't exist are skipped.
+func WriteBundle(archivePath, rootDir string, manifest BundleManifest, paths []string) (*BundleManifest, error) {
+	manifest.Files = nil
+	for _, p := range paths {
+		err := filepath.Walk(filepath.Join(rootDir, p), func(f string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			rel, err := filepath.Rel(rootDir, f)
+			if err != nil {
+				return err
+			}
+			sum, err := fileSHA256(f)
+			if err != nil {
+				return err
+			}
+			manifest.Files = append(manifest.Files, BundleFile{
+				Path:   filepath.ToSlash(rel),
+				Size:   info.Size(),
+				SHA256: sum,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("listing the files of %s: %w", p, err)
+		}
+	}
+	sort.Slice(manifest.Files, func(i, j int) bool {
+		return manifest.Files[i].Path < manifest.Files[j].Path
+	})
+
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshalling the manifest: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(archivePath), 0755); err != nil {
+		return nil, fmt.Errorf("creating the directory of %s: %w", archivePath, err)
+	}
+	out, err := os.Create(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("creating %s: %w", archivePath, err)
+	}
+	defer out.Close()
+
+	w, err := newArchiveWriter(archivePath, out)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.add(bundleManifestFile, int64(len(b)), strings.NewReader(string(b))); err != nil {
+		return nil, fmt.Errorf("writing the manifest: %w", err)
+	}
+	for _, file := range manifest.Files {
+		if err := addFileToArchive(w, file.Path, filepath.Join(rootDir, filepath.FromSlash(file.Path))); err != nil {
+			return nil, fmt.Errorf("writing %s: %w", file.Path, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("writing %s: %w", archivePath, err)
+	}
+	return &manifest, out.Close()
+}
+
+func addFileToArchive(w archiveWriter, name, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return w.add(name, info.Size(), f)
+}
+
+func fileSHA256(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ImportBundle verifies the files of a bundle against its manifest and
+// unpacks them into destDir. Nothing is written to destDir unless all of the
+// files are verified.
+func ImportBundle(archivePath, destDir string) (*BundleManifest, error) {
+	// The files are unpacked to a temporary directory, rather than into
+	// destDir, so that nothing is left in destDir if the import is killed
+	// before they're verified.
+	stageDir, err := os.MkdirTemp("", "registrygen-import-")
+	if err != nil {
+		return nil, fmt.Errorf("creating the staging directory: %w", err)
+	}
+	defer os.RemoveAll(stageDir)
+
+	var manifest *BundleManifest
+	unpacked := map[string]BundleFile{}
+	err = readArchive(archivePath, func(name string, r io.Reader) error {
+		if name == bundleManifestFile {
+			manifest = &BundleManifest{}
+			if err := json.NewDecoder(r).Decode(manifest); err != nil {
+				return fmt.Errorf("reading the manifest: %w", err)
+			}
+			return nil
+		}
+
+		rel, err := bundlePath(name)
+		if err != nil {
+			return err
+		}
+		file := filepath.Join(stageDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return err
+		}
+		f, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		h := sha256.New()
+		size, err := io.Copy(io.MultiWriter(f, h), r)
+		if err != nil {
+			return fmt.Errorf("unpacking %s: %w", name, err)
+		}
+		unpacked[rel] = BundleFile{Path: rel, Size: size, SHA256: hex.EncodeToString(h.Sum(nil))}
+		return f.Close()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", archivePath, err)
+	}
+
+	if err := verifyBundle(manifest, unpacked); err != nil {
+		return nil, fmt.Errorf("verifying %s: %w", archivePath, err)
+	}
+
+	// The files of the replaced directories are moved to a staging directory
+	// next to each of them, which is swapped into place once all of its files
+	// are there, so that a failure leaves the existing directory as is.
+	replaced := map[string][]BundleFile{}
+	for _, file := range manifest.Files {
+		dir := replacedDir(manifest.ReplaceDirs, file.Path)
+		if dir != "" {
+			replaced[dir] = append(replaced[dir], file)
+			continue
+		}
+		dest := filepath.Join(destDir, filepath.FromSlash(file.Path))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return nil, fmt.Errorf("creating the directory of %s: %w", file.Path, err)
+		}
+		if err := moveFile(filepath.Join(stageDir, filepath.FromSlash(file.Path)), dest); err != nil {
+			return nil, fmt.Errorf("writing %s: %w", file.Path, err)
+		}
+	}
+	for _, dir := range manifest.ReplaceDirs {
+		if err := importReplacedDir(stageDir, destDir, dir, replaced[dir]); err != nil {
+			return nil, fmt.Errorf("replacing %s: %w", dir, err)
+		}
+	}
+	return manifest, nil
+}
+
+// replacedDir returns the directory of dirs that a file is in, if any.
+func replacedDir(dirs []string, file string) string {
+	for _, dir := range dirs {
+		if strings.HasPrefix(file, dir+"/") {
+			return dir
+		}
+	}
+	return ""
+}
+
+// importReplacedDir replaces dir in destDir with the files of a bundle in
+// it, which are unpacked in stageDir.
+func importReplacedDir(stageDir, destDir, dir string, files []BundleFile) error {
+	dest := filepath.Join(destDir, filepath.FromSlash(dir))
+	dirStageDir, err := stagingDir(dest)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dirStageDir)
+
+	for _, file := range files {
+		rel := strings.TrimPrefix(file.Path, dir+"/")
+		fileDest := filepath.Join(dirStageDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(fileDest), 0755); err != nil {
+			return fmt.Errorf("creating the directory of %s: %w", file.Path, err)
+		}
+		if err := moveFile(filepath.Join(stageDir, filepath.FromSlash(file.Path)), fileDest); err != nil {
+			return fmt.Errorf("writing %s: %w", file.Path, err)
+		}
+	}
+	return swapDir(dest, dirStageDir)
+}
+
+// moveFile moves the file at src to dest. The file is copied if it can't be
+// renamed, e.g. because the temporary directory is on another file system.
+func moveFile(src, dest string) error {
+	if err := os.Rename(src, dest); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	// The copy is written next to dest and renamed into place so that dest is
+	// never partially written.
+	out, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	if err := out.Chmod(0644); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(out.Name(), dest)
+}
+
+// verifyBundle checks that the unpacked files are exactly the files of the
+// manifest, with the same sizes and SHA-256s, and that the manifest only
+// writes the paths of its package and only replaces its API docs directory.
+func verifyBundle(manifest *BundleManifest, unpacked map[string]BundleFile) error {
+	if manifest == nil {
+		return fmt.Errorf("the bundle has no %s", bundleManifestFile)
+	}
+	if manifest.Package == "" || strings.ContainsAny(manifest.Package, "/\\") ||
+		manifest.Package == "." || manifest.Package == ".." {
+		return fmt.Errorf("invalid package %q in the manifest", manifest.Package)
+	}
+
+	var problems []string
+	apiDocsDir := PackageAPIDocsDir(manifest.Package)
+	for _, dir := range manifest.ReplaceDirs {
+		if dir != apiDocsDir {
+			problems = append(problems, fmt.Sprintf("%s can't be replaced, only %s can", dir, apiDocsDir))
+		}
+	}
+	for _, file := range manifest.Files {
+		if !isPackagePath(manifest.Package, file.Path) {
+			problems = append(problems, fmt.Sprintf("%s isn't a path of package %s", file.Path, manifest.Package))
+		}
+	}
+	listed := map[string]bool{}
+	for _, file := range manifest.Files {
+		listed[file.Path] = true
+		got, ok := unpacked[file.Path]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s is missing", file.Path))
+		case got.Size != file.Size:
+			problems = append(problems, fmt.Sprintf("%s is %d bytes, expected %d", file.Path, got.Size, file.Size))
+		case got.SHA256 != file.SHA256:
+			problems = append(problems, fmt.Sprintf("%s has SHA-256 %s, expected %s", file.Path, got.SHA256, file.SHA256))
+		}
+	}
+	for p := range unpacked {
+		if !listed[p] {
+			problems = append(problems, fmt.Sprintf("%s isn't listed in the manifest", p))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("%d problem(s):\n  %s", len(problems), strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// bundlePath returns the cleaned path of a file of a bundle, which must be
+// relative and stay within the root of the bundle.
+func bundlePath(name string) (string, error) {
+	p := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if path.IsAbs(p) || p == "." || p == ".." || strings.HasPrefix(p, "../") {
+		return "", fmt.Errorf("invalid path %q in the bundle", name)
+	}
+	return p, nil
+}
+
+// archiveWriter writes the entries of an archive.
+type archiveWriter interface {
+	add(name string, size int64, r io.Reader) error
+	Close() error
+}
+
+// newArchiveWriter returns a writer for the format of the archive's
+// extension.
+func newArchiveWriter(archivePath string, w io.Writer) (archiveWriter, error) {
+	switch {
+	case strings.HasSuffix(archivePath, ".zip"):
+		return &zipArchiveWriter{zw: zip.NewWriter(w)}, nil
+	case strings.HasSuffix(archivePath, ".tar.gz"), strings.HasSuffix(archivePath, ".tgz"):
+		return newTarGzWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unsupported archive %s, expected a .tar.gz, .tgz or .zip file", archivePath)
+	}
+}
+
+// tarGzWriter writes a gzipped tarball whose entries have no timestamps, so
+// that the same content always results in the same tarball.
+type tarGzWriter struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+func newTarGzWriter(w io.Writer) *tarGzWriter {
+	gz := gzip.NewWriter(w)
+	return &tarGzWriter{gz: gz, tw: tar.NewWriter(gz)}
+}
+
+func (w *tarGzWriter) add(name string, size int64, r io.Reader) error {
+	hdr := &tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     size,
+		Typeflag: tar.TypeReg,
+		Format:   tar.FormatPAX,
+	}
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := io.Copy(w.tw, r)
+	return err
+}
+
+func (w *tarGzWriter) Close() error {
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+	return w.gz.Close()
+}
+
+type zipArchiveWriter struct {
+	zw *zip.Writer
+}
+
+func (w *zipArchiveWriter) add(name string, size int64, r io.Reader) error {
+	f, err := w.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	return err
+}
+
+func (w *zipArchiveWriter) Close() error {
+	return w.zw.Close()
+}
+
+// readArchive calls read with each file of a .tar.gz, .tgz or .zip archive.
+func readArchive(archivePath string, read func(name string, r io.Reader) error) error {
+	if strings.HasSuffix(archivePath, ".zip") {
+		zr, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			err = read(f.Name, rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !strings.HasSuffix(archivePath, ".tar.gz") && !strings.HasSuffix(archivePath, ".tgz") {
+		return fmt.Errorf("unsupported archive, expected a .tar.gz, .tgz or .zip file")
+	}
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			if err := read(hdr.Name, tr); err != nil {
+				return err
+			}
+		case tar.TypeDir:
+		default:
+			return fmt.Errorf("unsupported entry %s in the archive", hdr.Name)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestBundle writes a bundle of the files, keyed by their path in the
+// registry, with the given manifest, if any.
+func writeTestBundle(t *testing.T, manifest string, files map[string]string) string {
+	t.Helper()
+	archivePath := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	f, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w, err := newArchiveWriter(archivePath, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest != "" {
+		if err := w.add(bundleManifestFile, int64(len(manifest)), strings.NewReader(manifest)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, contents := range files {
+		if err := w.add(name, int64(len(contents)), strings.NewReader(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return archivePath
+}
+
+func TestImportBundle(t *testing.T) {
+	srcDir := t.TempDir()
+	apiDocsDir := PackageAPIDocsDir("random")
+	srcFiles := []string{
+		apiDocsDir + "/_index.md",
+		"themes/default/data/registry/packages/random.yaml",
+		"themes/default/static/registry/packages/navs/random.json",
+	}
+	for _, f := range srcFiles {
+		if err := EmitFile(srcDir, f, []byte("index")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archivePath := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	_, err := WriteBundle(archivePath, srcDir, BundleManifest{
+		Package:     "random",
+		Version:     "v4.3.1",
+		ReplaceDirs: []string{apiDocsDir},
+	}, []string{"themes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	destDir := t.TempDir()
+	if err := EmitFile(destDir, apiDocsDir+"/stale/_index.md", []byte("stale")); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := ImportBundle(archivePath, destDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Files) != len(srcFiles) {
+		t.Errorf("expected %d files to be imported, got %d", len(srcFiles), len(manifest.Files))
+	}
+
+	b, err := os.ReadFile(filepath.Join(destDir, filepath.FromSlash(apiDocsDir), "_index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "index" {
+		t.Errorf("expected _index.md to be imported, got %q", b)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, filepath.FromSlash(apiDocsDir), "stale")); !os.IsNotExist(err) {
+		t.Errorf("expected the stale API docs to be removed, got %v", err)
+	}
+	assertNoStagingLeftovers(t, destDir)
+}
+
+func TestImportBundleRejectsInvalidBundles(t *testing.T) {
+	apiDocsDir := PackageAPIDocsDir("random")
+	indexFile := apiDocsDir + "/_index.md"
+	// The SHA-256 of "index".
+	indexSHA256 := "1bc04b5291c26a46d918139138b992d2de976d6851d0893b0476b85bfbdfc6e6"
+
+	tests := []struct {
+		name     string
+		manifest string
+		files    map[string]string
+		problem  string
+	}{
+		{
+			name:     "no manifest",
+			manifest: "",
+			files:    map[string]string{indexFile: "index"},
+			problem:  "no manifest.json",
+		},
+		{
+			name: "mismatched SHA-256",
+			manifest: `{"package": "random", "files": [{"path": "` + indexFile + `", "size": 5, ` +
+				`"sha256": "0000000000000000000000000000000000000000000000000000000000000000"}]}`,
+			files:   map[string]string{indexFile: "index"},
+			problem: "has SHA-256",
+		},
+		{
+			name: "mismatched size",
+			manifest: `{"package": "random", "files": [{"path": "` + indexFile + `", "size": 6, ` +
+				`"sha256": "` + indexSHA256 + `"}]}`,
+			files:   map[string]string{indexFile: "index"},
+			problem: "is 5 bytes, expected 6",
+		},
+		{
+			name: "missing file",
+			manifest: `{"package": "random", "files": [{"path": "` + indexFile + `", "size": 5, ` +
+				`"sha256": "` + indexSHA256 + `"}, {"path": "` + apiDocsDir + `/other.md", "size": 1, "sha256": ""}]}`,
+			files:   map[string]string{indexFile: "index"},
+			problem: "other.md is missing",
+		},
+		{
+			name:     "unlisted file",
+			manifest: `{"package": "random", "files": []}`,
+			files:    map[string]string{indexFile: "index"},
+			problem:  "isn't listed in the manifest",
+		},
+		{
+			name: "replaced directory outside the API docs",
+			manifest: `{"package": "random", "replace_dirs": ["themes"], "files": [{"path": "` + indexFile + `", ` +
+				`"size": 5, "sha256": "` + indexSHA256 + `"}]}`,
+			files:   map[string]string{indexFile: "index"},
+			problem: "themes can't be replaced",
+		},
+		{
+			name: "file outside the package",
+			manifest: `{"package": "random", "files": [{"path": ".git/hooks/post-commit", "size": 5, ` +
+				`"sha256": "` + indexSHA256 + `"}]}`,
+			files:   map[string]string{".git/hooks/post-commit": "index"},
+			problem: ".git/hooks/post-commit isn't a path of package random",
+		},
+		{
+			name: "file of another package",
+			manifest: `{"package": "random", "files": [{"path": "themes/default/data/registry/packages/tls.yaml", ` +
+				`"size": 5, "sha256": "` + indexSHA256 + `"}]}`,
+			files:   map[string]string{"themes/default/data/registry/packages/tls.yaml": "index"},
+			problem: "isn't a path of package random",
+		},
+		{
+			name:     "path outside the registry",
+			manifest: `{"package": "random", "files": []}`,
+			files:    map[string]string{"../outside.md": "outside"},
+			problem:  "invalid path",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archivePath := writeTestBundle(t, tt.manifest, tt.files)
+
+			destDir := t.TempDir()
+			_, err := ImportBundle(archivePath, destDir)
+			if err == nil || !strings.Contains(err.Error(), tt.problem) {
+				t.Fatalf("expected an error containing %q, got %v", tt.problem, err)
+			}
+
+			entries, err := os.ReadDir(destDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("expected nothing to be written to the destination, got %d entries", len(entries))
+			}
+		})
+	}
+}
+
+func TestBundlePath(t *testing.T) {
+	for _, name := range []string{"a/b.md", "a/./b.md", "a\\b.md"} {
+		if _, err := bundlePath(name); err != nil {
+			t.Errorf("expected %q to be valid, got %v", name, err)
+		}
+	}
+	for _, name := range []string{"", ".", "..", "../a.md", "a/../../b.md", "/a.md", "\\a.md"} {
+		if _, err := bundlePath(name); err == nil {
+			t.Errorf("expected %q to be invalid", name)
+		}
+	}
+}
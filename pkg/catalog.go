@@ -0,0 +1,206 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// PackageKindComponent is the kind of packages that are components.
+	PackageKindComponent = "component"
+	// PackageKindNativeProvider is the kind of providers that don't use
+	// the TF bridge.
+	PackageKindNativeProvider = "native-provider"
+	// PackageKindBridgedProvider is the kind of providers that use the TF
+	// bridge.
+	PackageKindBridgedProvider = "bridged-provider"
+)
+
+// Catalog describes all of the packages in the registry grouped by their
+// category.
+type Catalog struct {
+	PackageCount int               `json:"package_count"`
+	Categories   []CatalogCategory `json:"categories"`
+}
+
+// CatalogCategory holds the packages of a single category.
+type CatalogCategory struct {
+	Category PackageCategory `json:"category"`
+	Packages []CatalogEntry  `json:"packages"`
+}
+
+// CatalogEntry is the metadata of a package along with the fields
+// computed from it and its schema.
+type CatalogEntry struct {
+	PackageMeta
+
+	Kind string `json:"kind"`
+	// Languages, ResourceCount and FunctionCount are computed from the
+	// package's schema and are omitted if the schema wasn't loaded.
+	Languages     []string `json:"languages,omitempty"`
+	ResourceCount *int     `json:"resource_count,omitempty"`
+	FunctionCount *int     `json:"function_count,omitempty"`
+}
+
+// ValidatePackageMeta returns the problems with the metadata of a package.
+func ValidatePackageMeta(metadata PackageMeta) []string {
+	var problems []string
+	if metadata.Name == "" {
+		problems = append(problems, "name is required")
+	}
+	if metadata.Title == "" {
+		problems = append(problems, "title is required")
+	}
+	if metadata.RepoURL == "" {
+		problems = append(problems, "repo_url is required")
+	}
+	if metadata.Version == "" {
+		problems = append(problems, "version is required")
+	}
+
+	validCategory := false
+	for _, c := range CategoryNameMap {
+		if metadata.Category == c {
+			validCategory = true
+			break
+		}
+	}
+	if !validCategory {
+		problems = append(problems, fmt.Sprintf("invalid category %q", metadata.Category))
+	}
+
+	validStatus := false
+	for _, s := range PackageStatusNameMap {
+		if metadata.PackageStatus == s {
+			validStatus = true
+			break
+		}
+	}
+	if !validStatus {
+		problems = append(problems, fmt.Sprintf("invalid package_status %q", metadata.PackageStatus))
+	}
+
+	return problems
+}
+
+// BuildCatalog validates the metadata of the packages and builds the
+// catalog of the registry. If withSchemas is true, the schema of each
+// package is downloaded to compute the language support and the number of
+// resources and functions.
+func BuildCatalog(ctx context.Context, packages []PackageMeta, withSchemas bool) (*Catalog, error) {
+	var problems []string
+	for _, p := range packages {
+		for _, problem := range ValidatePackageMeta(p) {
+			problems = append(problems, fmt.Sprintf("%s: %s", p.Name, problem))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid package metadata:\n  %s", strings.Join(problems, "\n  "))
+	}
+
+	byCategory := map[PackageCategory][]CatalogEntry{}
+	for _, p := range packages {
+		entry := CatalogEntry{
+			PackageMeta: p,
+			Kind:        packageKind(p),
+		}
+
+		if withSchemas {
+			glog.V(2).Infof("Loading the schema of %s@%s", p.Name, p.Version)
+			spec, err := FetchPackageSpec(ctx, p.RepoURL, p.Version, p.SchemaFilePath)
+			if err != nil {
+				return nil, fmt.Errorf("getting the schema of %s: %w", p.Name, err)
+			}
+
+			for lang := range spec.Language {
+				entry.Languages = append(entry.Languages, lang)
+			}
+			sort.Strings(entry.Languages)
+			resourceCount, functionCount := len(spec.Resources), len(spec.Functions)
+			entry.ResourceCount = &resourceCount
+			entry.FunctionCount = &functionCount
+		}
+
+		byCategory[p.Category] = append(byCategory[p.Category], entry)
+	}
+
+	catalog := &Catalog{PackageCount: len(packages)}
+	for category, entries := range byCategory {
+		sort.Slice(entries, func(i, j int) bool {
+			ti, tj := strings.ToLower(entries[i].Title), strings.ToLower(entries[j].Title)
+			if ti != tj {
+				return ti < tj
+			}
+			return entries[i].Name < entries[j].Name
+		})
+		catalog.Categories = append(catalog.Categories, CatalogCategory{Category: category, Packages: entries})
+	}
+	sort.Slice(catalog.Categories, func(i, j int) bool {
+		return catalog.Categories[i].Category < catalog.Categories[j].Category
+	})
+
+	return catalog, nil
+}
+
+// JSON returns the catalog as indented JSON.
+func (c *Catalog) JSON() ([]byte, error) {
+	return json.MarshalIndent(c, "", "  ")
+}
+
+// CSV returns the catalog as CSV with one row per package.
+func (c *Catalog) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	header := []string{
+		"name", "title", "category", "kind", "publisher", "version", "package_status",
+		"languages", "resource_count", "function_count", "repo_url", "updated_on",
+	}
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	optionalInt := func(i *int) string {
+		if i == nil {
+			return ""
+		}
+		return strconv.Itoa(*i)
+	}
+	for _, category := range c.Categories {
+		for _, p := range category.Packages {
+			record := []string{
+				p.Name, p.Title, string(p.Category), p.Kind, p.Publisher, p.Version, string(p.PackageStatus),
+				strings.Join(p.Languages, ";"), optionalInt(p.ResourceCount), optionalInt(p.FunctionCount),
+				p.RepoURL, strconv.FormatInt(p.UpdatedOn, 10),
+			}
+			if err := w.Write(record); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func packageKind(metadata PackageMeta) string {
+	switch {
+	case metadata.Component:
+		return PackageKindComponent
+	case metadata.Native:
+		return PackageKindNativeProvider
+	default:
+		return PackageKindBridgedProvider
+	}
+}
@@ -0,0 +1,293 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/ghodss/yaml"
+	"github.com/golang/glog"
+)
+
+const (
+	changelogFileName = "changelog.md"
+	// releasesPageSize is the maximum page size supported by the GitHub API.
+	releasesPageSize = 100
+
+	// DefaultRecentUpdatesLimit is the default number of packages in the
+	// registry's recent updates.
+	DefaultRecentUpdatesLimit = 20
+)
+
+var (
+	headingRegexp      = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	issueRefRegexp     = regexp.MustCompile(`(^|[\s(])#(\d+)\b`)
+	relativeLinkRegexp = regexp.MustCompile(`\]\(([^)\s]+)\)`)
+)
+
+// ChangelogOptions controls the generation of the changelog of a package.
+type ChangelogOptions struct {
+	RepoSlug     string
+	PackageTitle string
+	// FromVersion is the oldest release to include. If empty, all releases
+	// up to ToVersion are included.
+	FromVersion string
+	// ToVersion is the most recent release to include. If empty, all
+	// releases since FromVersion are included.
+	ToVersion string
+	// OutDir is the directory that changelog.md is written to.
+	OutDir string
+}
+
+// RecentUpdate is an entry of the registry-wide list of recently updated
+// packages.
+type RecentUpdate struct {
+	Name      string `json:"name"`
+	Title     string `json:"title"`
+	Version   string `json:"version"`
+	UpdatedOn int64  `json:"updated_on"`
+}
+
+// GenerateChangelog writes the release notes of the package's GitHub
+// releases within the version range to a changelog.md page.
+func GenerateChangelog(ctx context.Context, opts ChangelogOptions) error {
+	releases, err := getGitHubReleases(ctx, opts.RepoSlug)
+	if err != nil {
+		return err
+	}
+
+	releases, err = filterReleases(releases, opts.FromVersion, opts.ToVersion)
+	if err != nil {
+		return err
+	}
+
+	title := opts.PackageTitle
+	if title == "" {
+		title = opts.RepoSlug
+	}
+
+	frontMatter, err := yaml.Marshal(map[string]string{
+		"title":     title + " Changelog",
+		"meta_desc": fmt.Sprintf("Release notes for the %s package.", title),
+		"layout":    "changelog",
+	})
+	if err != nil {
+		return fmt.Errorf("marshalling the front matter of the changelog: %w", err)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "---\n%s---\n\n", frontMatter)
+
+	if len(releases) == 0 {
+		sb.WriteString("There are no releases for this package yet.\n")
+	}
+	for _, r := range releases {
+		fmt.Fprintf(&sb, "## %s\n\n", r.TagName)
+		if !r.PublishedAt.IsZero() {
+			fmt.Fprintf(&sb, "_Released on %s_", r.PublishedAt.Format("January 2, 2006"))
+		}
+		if r.HTMLURL != "" {
+			fmt.Fprintf(&sb, " ([view on GitHub](%s))", r.HTMLURL)
+		}
+		sb.WriteString("\n\n")
+
+		if notes := normalizeReleaseNotes(opts.RepoSlug, r.TagName, r.Body); notes != "" {
+			sb.WriteString(notes)
+			sb.WriteString("\n\n")
+		}
+	}
+
+	if err := EmitFile(opts.OutDir, changelogFileName, []byte(sb.String())); err != nil {
+		return fmt.Errorf("writing the changelog: %w", err)
+	}
+
+	return nil
+}
+
+// WriteRecentUpdates writes the packages in the registry packages
+// directory sorted by the date they were last updated, most recent first,
+// to the data file outFile. A limit of 0 includes all packages.
+func WriteRecentUpdates(registryPackagesPath, outFile string, limit int) error {
+	packages, err := LoadPackageMetadata(registryPackagesPath)
+	if err != nil {
+		return err
+	}
+
+	updates := make([]RecentUpdate, 0, len(packages))
+	for _, p := range packages {
+		updates = append(updates, RecentUpdate{
+			Name:      p.Name,
+			Title:     p.Title,
+			Version:   p.Version,
+			UpdatedOn: p.UpdatedOn,
+		})
+	}
+	sort.SliceStable(updates, func(i, j int) bool {
+		if updates[i].UpdatedOn != updates[j].UpdatedOn {
+			return updates[i].UpdatedOn > updates[j].UpdatedOn
+		}
+		return updates[i].Name < updates[j].Name
+	})
+	if limit > 0 && len(updates) > limit {
+		updates = updates[:limit]
+	}
+
+	b, err := yaml.Marshal(updates)
+	if err != nil {
+		return fmt.Errorf("marshalling the recent updates: %w", err)
+	}
+
+	if err := EmitFile(filepath.Dir(outFile), filepath.Base(outFile), b); err != nil {
+		return fmt.Errorf("writing the recent updates: %w", err)
+	}
+
+	return nil
+}
+
+func getGitHubReleases(ctx context.Context, repoSlug string) ([]GitHubRelease, error) {
+	var releases []GitHubRelease
+	for page := 1; ; page++ {
+		path := fmt.Sprintf("/repos/%s/releases?per_page=%d&page=%d", repoSlug, releasesPageSize, page)
+		resp, err := GetGitHubAPI(ctx, path)
+		if err != nil {
+			return nil, fmt.Errorf("getting releases for %s: %w", repoSlug, err)
+		}
+
+		var pageReleases []GitHubRelease
+		err = func() error {
+			defer resp.Body.Close()
+			if resp.StatusCode != 200 {
+				respBody, _ := io.ReadAll(resp.Body)
+				return fmt.Errorf("getting releases for %s: %s: %s", repoSlug, resp.Status, string(respBody))
+			}
+
+			return json.NewDecoder(resp.Body).Decode(&pageReleases)
+		}()
+		if err != nil {
+			return nil, fmt.Errorf("constructing releases information for %s: %w", repoSlug, err)
+		}
+
+		releases = append(releases, pageReleases...)
+		if len(pageReleases) < releasesPageSize {
+			return releases, nil
+		}
+	}
+}
+
+// filterReleases returns the published releases whose versions are within
+// the inclusive range [from, to] sorted newest first.
+func filterReleases(releases []GitHubRelease, from, to string) ([]GitHubRelease, error) {
+	var fromVersion, toVersion *semver.Version
+	if from != "" {
+		v, err := semver.ParseTolerant(from)
+		if err != nil {
+			return nil, fmt.Errorf("parsing version %s: %w", from, err)
+		}
+		fromVersion = &v
+	}
+	if to != "" {
+		v, err := semver.ParseTolerant(to)
+		if err != nil {
+			return nil, fmt.Errorf("parsing version %s: %w", to, err)
+		}
+		toVersion = &v
+	}
+
+	type versionedRelease struct {
+		version semver.Version
+		release GitHubRelease
+	}
+	var filtered []versionedRelease
+	for _, r := range releases {
+		if r.Draft {
+			continue
+		}
+
+		v, err := semver.ParseTolerant(r.TagName)
+		if err != nil {
+			glog.V(2).Infof("Skipping release %s which is not a semantic version", r.TagName)
+			continue
+		}
+		if fromVersion != nil && v.LT(*fromVersion) {
+			continue
+		}
+		if toVersion != nil && v.GT(*toVersion) {
+			continue
+		}
+
+		filtered = append(filtered, versionedRelease{version: v, release: r})
+	}
+
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].version.GT(filtered[j].version)
+	})
+
+	result := make([]GitHubRelease, 0, len(filtered))
+	for _, r := range filtered {
+		result = append(result, r.release)
+	}
+	return result, nil
+}
+
+// normalizeReleaseNotes demotes the headings of the release notes so that
+// they nest under the heading of the release, turns issue and pull request
+// references into links and resolves relative links against the repo at
+// the release's tag.
+func normalizeReleaseNotes(repoSlug, tag, body string) string {
+	lines := strings.Split(strings.ReplaceAll(strings.TrimSpace(body), "\r\n", "\n"), "\n")
+
+	minLevel := 0
+	inCodeBlock := false
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if m := headingRegexp.FindStringSubmatch(line); m != nil && !inCodeBlock {
+			if minLevel == 0 || len(m[1]) < minLevel {
+				minLevel = len(m[1])
+			}
+		}
+	}
+
+	repoURL := fmt.Sprintf("https://github.com/%s", repoSlug)
+	inCodeBlock = false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+
+		if m := headingRegexp.FindStringSubmatch(line); m != nil {
+			// The release itself is an h2, so its notes start at h3.
+			level := len(m[1]) - minLevel + 3
+			if level > 6 {
+				level = 6
+			}
+			line = strings.Repeat("#", level) + " " + m[2]
+		}
+
+		line = issueRefRegexp.ReplaceAllString(line, fmt.Sprintf("$1[#$2](%s/issues/$2)", repoURL))
+		line = relativeLinkRegexp.ReplaceAllStringFunc(line, func(link string) string {
+			target := link[2 : len(link)-1]
+			if strings.Contains(target, "://") || strings.HasPrefix(target, "#") ||
+				strings.HasPrefix(target, "/") || strings.HasPrefix(target, "mailto:") {
+				return link
+			}
+			return fmt.Sprintf("](%s/blob/%s/%s)", repoURL, tag, strings.TrimPrefix(target, "./"))
+		})
+
+		lines[i] = line
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -1,15 +1,12 @@
 package pkg
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"net/url"
-	"os"
-	"strings"
+	"path/filepath"
 
-	"github.com/ghodss/yaml"
 	docsgen "github.com/pulumi/pulumi/pkg/v3/codegen/docs"
 	"github.com/pulumi/pulumi/pkg/v3/codegen/dotnet"
 	go_gen "github.com/pulumi/pulumi/pkg/v3/codegen/go"
@@ -39,14 +36,8 @@ func getRepoSlug(repoURL string) (string, error) {
 	return u.Path, nil
 }
 
-func getPulumiPackageFromSchema(docsOutDir string) (*pschema.Package, error) {
-
-	// Delete existing docs before generating new ones.
-	if err := os.RemoveAll(docsOutDir); err != nil {
-		return nil, fmt.Errorf("deleting provider directory %v: %w", docsOutDir, err)
-	}
-
-	pulPkg, err := pschema.ImportSpec(*mainSpec, nil)
+func getPulumiPackageFromSchema(loader pschema.Loader) (*pschema.Package, error) {
+	pulPkg, err := bindPackageSpec(mainSpec, loader)
 	if err != nil {
 		return nil, fmt.Errorf("error importing package spec: %w", err)
 	}
@@ -56,54 +47,186 @@ func getPulumiPackageFromSchema(docsOutDir string) (*pschema.Package, error) {
 	return pulPkg, nil
 }
 
-func GenerateDocs(repoURL, version, schemaFile, docsOutDir, packageTreeJSONOutDir string) error {
-	repoSlug, err := getRepoSlug(repoURL)
+// GenerateDocsOptions controls the generation of the API docs for a
+// single package.
+type GenerateDocsOptions struct {
+	RepoURL               string
+	Version               string
+	SchemaFile            string
+	DocsOutDir            string
+	PackageTreeJSONOutDir string
+	// PerPackageDocsOutDir writes the docs to a sub-directory of DocsOutDir
+	// named after the package, for repos that publish multiple packages.
+	PerPackageDocsOutDir bool
+
+	// PackageStatus overrides the status that is otherwise determined from
+	// the package's schema and version.
+	PackageStatus PackageStatus
+	// DeprecationMessage and SupersededBy are shown in the banner added to
+	// the pages of deprecated packages.
+	DeprecationMessage string
+	SupersededBy       string
+
+	// Versioned writes the docs to a sub-directory of DocsOutDir named after
+	// the major version of the package instead of replacing all of the docs
+	// in DocsOutDir, and maintains a versions.json index of the retained
+	// major versions.
+	Versioned bool
+	// KeepVersions is the number of major versions whose docs are retained
+	// in versioned mode. 0 retains all of them.
+	KeepVersions int
+
+	// SearchIndexOutDir is the directory to write the package's search index
+	// shard to. No search index is written if empty.
+	SearchIndexOutDir string
+
+	// Sitemap collects the URLs of the generated pages, if set. UpdatedOn is
+	// the Unix timestamp used as the last modification date of the pages.
+	Sitemap   *Sitemap
+	UpdatedOn int64
+
+	// Languages restricts the languages rendered in the docs. If empty, the
+	// languages declared in the language section of the schema are used.
+	Languages []string
+
+	// SchemaLoader loads the packages whose types are referenced by the
+	// schema so that the docs can link to their pages. The references to
+	// other packages can't be resolved if nil.
+	SchemaLoader pschema.Loader
+
+	// Output writes the docs in the format of another docs site instead of
+	// the Markdown of the registry, if set.
+	Output OutputAdapter
+}
+
+func GenerateDocs(ctx context.Context, opts GenerateDocsOptions) error {
+	var err error
+	mainSpec, err = FetchPackageSpec(ctx, opts.RepoURL, opts.Version, opts.SchemaFile)
 	if err != nil {
 		return err
 	}
 
-	// we should be able to take the repo URL + the version + the schema url and
-	// construct a file that we can download and read
-	schemaFilePath := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s", repoSlug, version, schemaFile)
-	resp, err := http.Get(schemaFilePath)
-	if err != nil {
-		return fmt.Errorf("downloading schema file from %s: %w", schemaFile, err)
+	status := opts.PackageStatus
+	if status == "" {
+		status, err = GetPackageStatus(mainSpec.Keywords, opts.Version)
+		if err != nil {
+			return fmt.Errorf("getting package status: %w", err)
+		}
 	}
 
-	defer resp.Body.Close()
-	schema, err := ioutil.ReadAll(resp.Body)
+	languages, err := resolveDocsLanguages(opts.Languages, mainSpec)
 	if err != nil {
-		return fmt.Errorf("reading contents of schema file: %w", err)
+		return fmt.Errorf("getting the languages of the docs: %w", err)
 	}
 
-	// The source schema can be in YAML format. If that's the case
-	// convert it to JSON first.
-	if strings.HasSuffix(schemaFile, ".yaml") {
-		schema, err = yaml.YAMLToJSON(schema)
+	baseDocsOutDir := opts.DocsOutDir
+	if opts.PerPackageDocsOutDir {
+		baseDocsOutDir = filepath.Join(opts.DocsOutDir, mainSpec.Name)
+	}
+
+	docsOutDir := baseDocsOutDir
+	major := ""
+	if opts.Versioned {
+		major, err = MajorVersion(opts.Version)
 		if err != nil {
-			return fmt.Errorf("reading YAML schema: %w", err)
+			return err
 		}
-	}
+		docsOutDir = filepath.Join(baseDocsOutDir, major)
 
-	mainSpec = &pschema.PackageSpec{}
-	if err := json.Unmarshal(schema, mainSpec); err != nil {
-		return fmt.Errorf("unmarshalling schema into a PackageSpec: %w", err)
+		// The docs of a major version that is pruned by the retention
+		// policy aren't generated, since they would be removed right away.
+		if err := checkVersionRetained(baseDocsOutDir, opts.Version, opts.KeepVersions); err != nil {
+			return err
+		}
 	}
-	mainSpec.Version = version
 
-	pulPkg, err := getPulumiPackageFromSchema(docsOutDir)
+	pulPkg, err := getPulumiPackageFromSchema(opts.SchemaLoader)
 	if err != nil {
 		return fmt.Errorf("generating package from schema file: %w", err)
 	}
+	// Binding a large schema takes a while, so the generation is stopped
+	// before it starts if it was canceled in the meantime.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
-	if err := generateDocsFromSchema(docsOutDir, pulPkg); err != nil {
+	var transforms []func([]byte) []byte
+	if link := linkExternalTypes(pulPkg); link != nil {
+		transforms = append(transforms, link)
+	}
+	if languages != nil {
+		transforms = append(transforms, func(contents []byte) []byte {
+			return filterLanguages(contents, languages)
+		})
+	}
+	if banner := deprecationBanner(pulPkg.Name, status, opts.DeprecationMessage, opts.SupersededBy); banner != nil {
+		transforms = append(transforms, func(contents []byte) []byte {
+			return injectBanner(contents, banner)
+		})
+	}
+
+	files, err := generateDocsFromSchema(pulPkg, transforms...)
+	if err != nil {
 		return fmt.Errorf("generating docs from schema: %w", err)
 	}
 
-	if err := generatePackageTree(packageTreeJSONOutDir, pulPkg.Name); err != nil {
+	outFiles := files
+	if opts.Output != nil {
+		tree, err := docsgen.GeneratePackageTree()
+		if err != nil {
+			return fmt.Errorf("generating the package tree: %w", err)
+		}
+		meta := outputPackageMeta(mainSpec, opts.Version, status)
+		outFiles, err = opts.Output.ConvertPackage(meta, docsOutDir, files, tree)
+		if err != nil {
+			return fmt.Errorf("converting the docs: %w", err)
+		}
+	}
+
+	// The existing docs are only replaced once all of the new docs are
+	// generated and written, and not at all if the generation is canceled.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := replaceDir(docsOutDir, outFiles); err != nil {
+		return fmt.Errorf("writing the docs to %s: %w", docsOutDir, err)
+	}
+
+	if opts.SearchIndexOutDir != "" {
+		records := buildSearchIndex(pulPkg, files, apiDocsURLPath(pulPkg.Name, major))
+		if err := writeSearchIndex(opts.SearchIndexOutDir, pulPkg.Name, records); err != nil {
+			return fmt.Errorf("generating search index: %w", err)
+		}
+	}
+
+	if opts.Sitemap != nil {
+		opts.Sitemap.addPages(apiDocsURLPath(pulPkg.Name, major), files, opts.UpdatedOn)
+	}
+
+	if !opts.Versioned {
+		if err := generatePackageTree(opts.PackageTreeJSONOutDir, pulPkg.Name); err != nil {
+			return fmt.Errorf("generating package tree: %w", err)
+		}
+		return nil
+	}
+
+	// Each major version has its own nav tree. The nav tree of the latest
+	// major version is also written to the unversioned location.
+	if err := generatePackageTree(filepath.Join(opts.PackageTreeJSONOutDir, pulPkg.Name), major); err != nil {
 		return fmt.Errorf("generating package tree: %w", err)
 	}
 
+	index, err := updateVersionIndex(baseDocsOutDir, opts.PackageTreeJSONOutDir, pulPkg.Name, opts.Version, opts.KeepVersions)
+	if err != nil {
+		return fmt.Errorf("updating the version index: %w", err)
+	}
+
+	if index.Latest == major {
+		if err := generatePackageTree(opts.PackageTreeJSONOutDir, pulPkg.Name); err != nil {
+			return fmt.Errorf("generating package tree: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -202,18 +325,22 @@ func mergeOverlaySchemaSpec(mainSpec *pschema.PackageSpec, overlaySpec *pschema.
 	return nil
 }
 
-func generateDocsFromSchema(outDir string, pulPkg *pschema.Package) error {
+// generateDocsFromSchema returns the API docs of the package keyed by their
+// path relative to the docs directory. The transforms are applied to the
+// contents of each page.
+func generateDocsFromSchema(pulPkg *pschema.Package, transforms ...func([]byte) []byte) (map[string][]byte, error) {
 	files, err := docsgen.GeneratePackage(tool, pulPkg)
 	if err != nil {
-		return fmt.Errorf("generating Pulumi package: %w", err)
+		return nil, fmt.Errorf("generating Pulumi package: %w", err)
 	}
 
 	for f, contents := range files {
-		if err := EmitFile(outDir, f, contents); err != nil {
-			return fmt.Errorf("emitting file %v: %w", f, err)
+		for _, transform := range transforms {
+			contents = transform(contents)
 		}
+		files[f] = contents
 	}
-	return nil
+	return files, nil
 }
 
 func generatePackageTree(outDir string, pkgName string) error {
@@ -0,0 +1,292 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	docsgen "github.com/pulumi/pulumi/pkg/v3/codegen/docs"
+)
+
+// docusaurusSidebarsFile is written next to the docs directory, where
+// Docusaurus looks for it by default.
+const docusaurusSidebarsFile = "sidebars.js"
+
+var (
+	htmlCommentRegexp = regexp.MustCompile(`(?s)<!--.*?-->`)
+	autolinkRegexp    = regexp.MustCompile(`<(https?://[^\s<>]+)>`)
+	inlineCodeRegexp  = regexp.MustCompile("`[^`\n]*`")
+	jsxTagRegexp      = regexp.MustCompile(`</?([a-zA-Z][\w-]*)((?:\s+[^\s=<>/]+(?:\s*=\s*(?:"[^"]*"|'[^']*'|[^\s"'<>]+))?)*)\s*/?>`)
+	jsxAttrRegexp     = regexp.MustCompile(`([^\s=<>/]+)(?:\s*=\s*("[^"]*"|'[^']*'|[^\s"'<>]+))?`)
+	// The heading IDs are supported by Docusaurus, so their braces aren't
+	// escaped.
+	mdxBraceRegexp = regexp.MustCompile(`\{#[\w-]+\}|[{}]`)
+
+	// mdxElements are the elements that are kept as JSX. The other tags,
+	// e.g. the placeholders like <region> in the descriptions of the schema,
+	// are escaped.
+	mdxElements = map[string]bool{
+		"a": true, "b": true, "blockquote": true, "br": true, "code": true, "dd": true, "del": true,
+		"details": true, "div": true, "dl": true, "dt": true, "em": true, "h1": true, "h2": true, "h3": true,
+		"h4": true, "h5": true, "h6": true, "hr": true, "i": true, "img": true, "kbd": true, "li": true,
+		"ol": true, "p": true, "pre": true, "s": true, "span": true, "strong": true, "sub": true,
+		"summary": true, "sup": true, "table": true, "tbody": true, "td": true, "th": true, "thead": true,
+		"tr": true, "u": true, "ul": true, "wbr": true, "Tabs": true, "TabItem": true,
+	}
+	voidElements = map[string]bool{"br": true, "hr": true, "img": true, "wbr": true}
+)
+
+// DocusaurusAdapter writes the API docs as MDX pages for Docusaurus, with the
+// language choosers rendered as synced tabs, and the package trees as a
+// sidebars.js with a sidebar per package.
+type DocusaurusAdapter struct {
+	packages []adapterPackage
+}
+
+// NewDocusaurusAdapter returns an adapter with no packages.
+func NewDocusaurusAdapter() *DocusaurusAdapter {
+	return &DocusaurusAdapter{}
+}
+
+// ConvertPackage converts each page to <dir>/index.mdx.
+func (a *DocusaurusAdapter) ConvertPackage(meta PackageMeta, outDir string, files map[string][]byte,
+	nav []docsgen.PackageTreeItem) (map[string][]byte, error) {
+	out := map[string][]byte{}
+	for f, contents := range files {
+		page := convertDocsPage(meta.Name, f, contents, docusaurusTabs)
+
+		fm, err := yaml.Marshal(map[string]string{
+			"title":       page.Title,
+			"description": page.Description,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("marshalling the front matter of %s: %w", f, err)
+		}
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "---\n%s---\n\n", fm)
+		if strings.Contains(page.Body, "<Tabs") {
+			sb.WriteString("import Tabs from '@theme/Tabs';\nimport TabItem from '@theme/TabItem';\n\n")
+		}
+		sb.WriteString(toMDX(page.Body))
+
+		out[path.Join(path.Dir(f), "index.mdx")] = []byte(sb.String())
+	}
+
+	a.packages = append(a.packages, adapterPackage{Meta: meta, OutDir: outDir, Nav: nav})
+	return out, nil
+}
+
+// Write writes the sidebars of the packages to sidebars.js in the parent
+// directory of docsDir, with the IDs of the pages relative to docsDir.
+func (a *DocusaurusAdapter) Write(docsDir string) error {
+	sidebars := map[string]interface{}{}
+	for _, p := range a.packages {
+		dir, err := p.docsDirPath(docsDir)
+		if err != nil {
+			return err
+		}
+		// The docs of each major version of a versioned package have their
+		// own sidebar.
+		name := p.Meta.Name
+		if _, ok := sidebars[name]; ok {
+			name += "-" + filepath.Base(p.OutDir)
+		}
+
+		items := []interface{}{map[string]interface{}{
+			"type":  "doc",
+			"id":    dir + "index",
+			"label": p.Meta.Title,
+		}}
+		sidebars[name] = append(items, docusaurusSidebarItems(resolveNav(p.Nav, dir))...)
+	}
+
+	b, err := json.MarshalIndent(sidebars, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling the sidebars: %w", err)
+	}
+	contents := fmt.Sprintf("// This file was generated by registrygen.\nmodule.exports = %s;\n", b)
+	if err := EmitFile(filepath.Dir(filepath.Clean(docsDir)), docusaurusSidebarsFile, []byte(contents)); err != nil {
+		return fmt.Errorf("writing %s: %w", docusaurusSidebarsFile, err)
+	}
+	return nil
+}
+
+func docusaurusSidebarItems(nav []adapterNavItem) []interface{} {
+	var items []interface{}
+	for _, item := range nav {
+		id := item.Dir + "index"
+		if len(item.Children) == 0 {
+			items = append(items, map[string]interface{}{
+				"type":  "doc",
+				"id":    id,
+				"label": item.Name,
+			})
+			continue
+		}
+		items = append(items, map[string]interface{}{
+			"type":  "category",
+			"label": item.Name,
+			"link":  map[string]string{"type": "doc", "id": id},
+			"items": docusaurusSidebarItems(item.Children),
+		})
+	}
+	return items
+}
+
+// docusaurusTabs renders tabs that are synced across the pages by language.
+func docusaurusTabs(tabs []docsTab) string {
+	var sb strings.Builder
+	sb.WriteString(`<Tabs groupId="language">`)
+	for _, tab := range tabs {
+		fmt.Fprintf(&sb, "\n<TabItem value=%q label=%q>\n\n%s\n\n</TabItem>", tab.Language, tab.Label, tab.Content)
+	}
+	sb.WriteString("\n</Tabs>")
+	return sb.String()
+}
+
+// toMDX makes the Markdown and the HTML of a page valid MDX: the braces and
+// the unknown tags of the text are escaped, and the tags of the HTML are
+// converted to JSX. The code blocks and code spans are left as is.
+func toMDX(body string) string {
+	body = htmlCommentRegexp.ReplaceAllString(body, "")
+
+	var sb strings.Builder
+	var text strings.Builder
+	fence := ""
+	for _, line := range strings.SplitAfter(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")):
+			sb.WriteString(mdxText(text.String()))
+			text.Reset()
+			// The fence is closed by a line of at least as many of the same
+			// characters.
+			fence = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+			sb.WriteString(line)
+		case fence != "":
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+			sb.WriteString(line)
+		default:
+			text.WriteString(line)
+		}
+	}
+	sb.WriteString(mdxText(text.String()))
+	return sb.String()
+}
+
+// mdxText converts the text outside of the code blocks, skipping code spans.
+func mdxText(text string) string {
+	var sb strings.Builder
+	last := 0
+	for _, m := range inlineCodeRegexp.FindAllStringIndex(text, -1) {
+		sb.WriteString(mdxMarkup(text[last:m[0]]))
+		sb.WriteString(text[m[0]:m[1]])
+		last = m[1]
+	}
+	sb.WriteString(mdxMarkup(text[last:]))
+	return sb.String()
+}
+
+func mdxMarkup(text string) string {
+	text = autolinkRegexp.ReplaceAllString(text, "[$1]($1)")
+
+	var sb strings.Builder
+	last := 0
+	for _, m := range jsxTagRegexp.FindAllStringSubmatchIndex(text, -1) {
+		name := text[m[2]:m[3]]
+		if !mdxElements[name] {
+			continue
+		}
+		sb.WriteString(escapeMDXText(text[last:m[0]]))
+		sb.WriteString(jsxTag(text[m[0]:m[1]], name, text[m[4]:m[5]]))
+		last = m[1]
+	}
+	sb.WriteString(escapeMDXText(text[last:]))
+	return sb.String()
+}
+
+func escapeMDXText(text string) string {
+	text = strings.ReplaceAll(text, "<", "&lt;")
+	return mdxBraceRegexp.ReplaceAllStringFunc(text, func(s string) string {
+		switch s {
+		case "{":
+			return "&#123;"
+		case "}":
+			return "&#125;"
+		}
+		return s
+	})
+}
+
+// jsxTag converts an HTML tag to JSX.
+func jsxTag(tag, name, attrs string) string {
+	if strings.HasPrefix(tag, "</") {
+		if voidElements[name] {
+			return ""
+		}
+		return "</" + name + ">"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<" + name)
+	for _, m := range jsxAttrRegexp.FindAllStringSubmatch(attrs, -1) {
+		attr, value := m[1], m[2]
+		switch attr {
+		case "class":
+			attr = "className"
+		case "for":
+			attr = "htmlFor"
+		}
+		sb.WriteString(" " + attr)
+		if value == "" {
+			continue
+		}
+		if value[0] == '"' || value[0] == '\'' {
+			value = value[1 : len(value)-1]
+		}
+		if attr == "style" {
+			sb.WriteString("={" + jsxStyle(value) + "}")
+			continue
+		}
+		sb.WriteString("=" + jsxString(value))
+	}
+	if voidElements[name] || strings.HasSuffix(tag, "/>") {
+		sb.WriteString(" />")
+	} else {
+		sb.WriteString(">")
+	}
+	return sb.String()
+}
+
+func jsxString(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, "&quot;") + `"`
+}
+
+// jsxStyle converts an inline style to a JSX style object, e.g.
+// "text-decoration: inherit" to {textDecoration: "inherit"}.
+func jsxStyle(style string) string {
+	var props []string
+	for _, decl := range strings.Split(style, ";") {
+		parts := strings.SplitN(decl, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name := strings.TrimSpace(parts[0])
+		words := strings.Split(name, "-")
+		for i := 1; i < len(words); i++ {
+			if words[i] != "" {
+				words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+			}
+		}
+		b, _ := json.Marshal(strings.TrimSpace(parts[1]))
+		props = append(props, fmt.Sprintf("%s: %s", strings.Join(words, ""), b))
+	}
+	return "{" + strings.Join(props, ", ") + "}"
+}
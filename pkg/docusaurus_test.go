@@ -0,0 +1,260 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	docsgen "github.com/pulumi/pulumi/pkg/v3/codegen/docs"
+)
+
+func TestToMDX(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name:     "braces are escaped",
+			body:     "A map of {key: value}.\n",
+			expected: "A map of &#123;key: value&#125;.\n",
+		},
+		{
+			name:     "heading IDs are kept",
+			body:     "## Inputs {#inputs}\n",
+			expected: "## Inputs {#inputs}\n",
+		},
+		{
+			name:     "placeholders are escaped",
+			body:     "The ARN is arn:aws:s3:<region>:<account>.\n",
+			expected: "The ARN is arn:aws:s3:&lt;region>:&lt;account>.\n",
+		},
+		{
+			name:     "code fences are left as is",
+			body:     "```go\nm := map[string]int{}\n// <region>\n```\n{}\n",
+			expected: "```go\nm := map[string]int{}\n// <region>\n```\n&#123;&#125;\n",
+		},
+		{
+			name:     "code fences of tildes and longer fences",
+			body:     "~~~\n{a}\n~~~\n````\n```\n{b}\n````\n{c}\n",
+			expected: "~~~\n{a}\n~~~\n````\n```\n{b}\n````\n&#123;c&#125;\n",
+		},
+		{
+			name:     "code spans are left as is",
+			body:     "Set `{\"a\": <b>}` or {c}.\n",
+			expected: "Set `{\"a\": <b>}` or &#123;c&#125;.\n",
+		},
+		{
+			name:     "HTML comments are removed",
+			body:     "a<!-- {b} -->c\n",
+			expected: "ac\n",
+		},
+		{
+			name:     "autolinks become links",
+			body:     "See <https://example.com/a>.\n",
+			expected: "See [https://example.com/a](https://example.com/a).\n",
+		},
+		{
+			name:     "class and style attributes",
+			body:     `<span class="property-type" style="text-decoration: inherit; font-weight: bold">string</span>` + "\n",
+			expected: `<span className="property-type" style={{textDecoration: "inherit", fontWeight: "bold"}}>string</span>` + "\n",
+		},
+		{
+			name:     "void elements are closed",
+			body:     "a<br>b<img src=\"x.png\"></img>\n",
+			expected: "a<br />b<img src=\"x.png\" />\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := toMDX(tt.body); actual != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestJSXTag(t *testing.T) {
+	tests := []struct {
+		tag      string
+		expected string
+	}{
+		{tag: `<a href="/docs/">`, expected: `<a href="/docs/">`},
+		{tag: `<a href='/a"b'>`, expected: `<a href="/a&quot;b">`},
+		{tag: `<td class=x>`, expected: `<td className="x">`},
+		{tag: `<label for="name">`, expected: `<label htmlFor="name">`},
+		{tag: `<details open>`, expected: `<details open>`},
+		{tag: `<div style="margin-left: 1em">`, expected: `<div style={{marginLeft: "1em"}}>`},
+		{tag: `<span/>`, expected: `<span />`},
+		{tag: `</span>`, expected: `</span>`},
+		{tag: `<br>`, expected: `<br />`},
+		{tag: `</br>`, expected: ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			m := jsxTagRegexp.FindStringSubmatch(tt.tag)
+			if m == nil {
+				t.Fatalf("%s isn't a tag", tt.tag)
+			}
+			if actual := jsxTag(m[0], m[1], m[2]); actual != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestJSXStyle(t *testing.T) {
+	tests := []struct {
+		style    string
+		expected string
+	}{
+		{style: "", expected: "{}"},
+		{style: "color: red", expected: `{color: "red"}`},
+		{style: "text-decoration: inherit;", expected: `{textDecoration: "inherit"}`},
+		{style: "border-top-width:1px; invalid; font-family: \"a\"", expected: `{borderTopWidth: "1px", fontFamily: "\"a\""}`},
+		{style: "background: url(a:b)", expected: `{background: "url(a:b)"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			if actual := jsxStyle(tt.style); actual != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDocusaurusAdapterConvertPackage(t *testing.T) {
+	adapter := NewDocusaurusAdapter()
+	files, err := adapter.ConvertPackage(PackageMeta{Name: "random", Title: "Random"}, t.TempDir(),
+		map[string][]byte{
+			"randompet/_index.md": []byte(`---
+title: RandomPet
+meta_desc: "Docs for the random.RandomPet resource."
+---
+
+A map of {key: value}.
+
+<pulumi-choosable type="language" values="python">py</pulumi-choosable>
+<pulumi-choosable type="language" values="go">go</pulumi-choosable>
+`),
+		}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `---
+description: Docs for the random.RandomPet resource.
+title: RandomPet
+---
+
+import Tabs from '@theme/Tabs';
+import TabItem from '@theme/TabItem';
+
+A map of &#123;key: value&#125;.
+
+
+
+<Tabs groupId="language">
+<TabItem value="python" label="Python">
+
+py
+
+</TabItem>
+<TabItem value="go" label="Go">
+
+go
+
+</TabItem>
+</Tabs>
+`
+	if actual := string(files["randompet/index.mdx"]); actual != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestDocusaurusAdapterWriteSidebars(t *testing.T) {
+	siteDir := t.TempDir()
+	docsDir := filepath.Join(siteDir, "docs")
+	nav := []docsgen.PackageTreeItem{
+		{
+			Name: "random",
+			Link: "random/",
+			Children: []docsgen.PackageTreeItem{
+				{Name: "RandomPet", Link: "randompet/"},
+			},
+		},
+	}
+
+	adapter := NewDocusaurusAdapter()
+	// The docs of a versioned package are in a directory per major version.
+	for _, major := range []string{"v4", "v3"} {
+		_, err := adapter.ConvertPackage(PackageMeta{Name: "random", Title: "Random"},
+			filepath.Join(docsDir, "random", major), map[string][]byte{}, nav)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := adapter.Write(docsDir); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(siteDir, docusaurusSidebarsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `// This file was generated by registrygen.
+module.exports = {
+  "random": [
+    {
+      "id": "random/v4/index",
+      "label": "Random",
+      "type": "doc"
+    },
+    {
+      "items": [
+        {
+          "id": "random/v4/random/randompet/index",
+          "label": "RandomPet",
+          "type": "doc"
+        }
+      ],
+      "label": "random",
+      "link": {
+        "id": "random/v4/random/index",
+        "type": "doc"
+      },
+      "type": "category"
+    }
+  ],
+  "random-v3": [
+    {
+      "id": "random/v3/index",
+      "label": "Random",
+      "type": "doc"
+    },
+    {
+      "items": [
+        {
+          "id": "random/v3/random/randompet/index",
+          "label": "RandomPet",
+          "type": "doc"
+        }
+      ],
+      "label": "random",
+      "link": {
+        "id": "random/v3/random/index",
+        "type": "doc"
+      },
+      "type": "category"
+    }
+  ]
+};
+`
+	if actual := string(b); actual != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+	if strings.Contains(string(b), siteDir) {
+		t.Errorf("expected the IDs to be relative to the docs directory:\n%s", b)
+	}
+}
@@ -0,0 +1,192 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+const (
+	exampleShortcodeStart = "{{% example %}}"
+	exampleShortcodeEnd   = "{{% /example %}}"
+	codeFence             = "```"
+)
+
+// exampleFileExtensions maps the language of an example's code fence to
+// the extension of the file the example is extracted to.
+var exampleFileExtensions = map[string]string{
+	"typescript": "ts",
+	"javascript": "js",
+	"python":     "py",
+	"go":         "go",
+	"csharp":     "cs",
+	"java":       "java",
+	"yaml":       "yaml",
+}
+
+var nonSlugCharsRegexp = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Example is an example from the description of a resource or function.
+type Example struct {
+	Token string
+	// Kind is either resource or function.
+	Kind  string
+	Title string
+	// Snippets is a map of language to the code of the example in that
+	// language.
+	Snippets map[string]string
+}
+
+// ExampleManifest lists the files an extracted set of examples is made of.
+type ExampleManifest struct {
+	Examples []ExampleManifestEntry `json:"examples"`
+}
+
+// ExampleManifestEntry describes the file of an example in one language.
+type ExampleManifestEntry struct {
+	Token    string `json:"token"`
+	Kind     string `json:"kind"`
+	Title    string `json:"title"`
+	Language string `json:"language"`
+	// Path is the path of the file relative to the output directory.
+	Path string `json:"path"`
+}
+
+// GetExamples returns the examples in the descriptions of the resources and
+// functions of a package.
+func GetExamples(pulPkg *pschema.Package) []Example {
+	var examples []Example
+
+	resources := pulPkg.Resources
+	if pulPkg.Provider != nil {
+		resources = append([]*pschema.Resource{pulPkg.Provider}, resources...)
+	}
+	for _, r := range resources {
+		for _, e := range parseExamples(r.Comment) {
+			e.Token, e.Kind = r.Token, "resource"
+			examples = append(examples, e)
+		}
+	}
+
+	for _, f := range pulPkg.Functions {
+		if f.IsMethod {
+			continue
+		}
+		for _, e := range parseExamples(f.Comment) {
+			e.Token, e.Kind = f.Token, "function"
+			examples = append(examples, e)
+		}
+	}
+
+	return examples
+}
+
+// parseExamples returns the examples within the {{% example %}} shortcodes
+// of a description. The title of an example is its first heading and each
+// code fence is a snippet in the language of the fence.
+func parseExamples(description string) []Example {
+	var examples []Example
+	var current *Example
+	var lang string
+	var code []string
+	inCodeBlock := false
+
+	for _, line := range strings.Split(strings.ReplaceAll(description, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case inCodeBlock:
+			if trimmed == codeFence {
+				inCodeBlock = false
+				if current != nil && lang != "" {
+					current.Snippets[lang] = strings.Join(code, "\n") + "\n"
+				}
+				continue
+			}
+			code = append(code, line)
+		case trimmed == exampleShortcodeStart:
+			current = &Example{Snippets: map[string]string{}}
+		case trimmed == exampleShortcodeEnd:
+			if current != nil && len(current.Snippets) > 0 {
+				examples = append(examples, *current)
+			}
+			current = nil
+		case current == nil:
+			continue
+		case strings.HasPrefix(trimmed, codeFence):
+			inCodeBlock = true
+			lang = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(trimmed, codeFence)))
+			code = nil
+		case strings.HasPrefix(trimmed, "#") && current.Title == "":
+			current.Title = strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+		}
+	}
+
+	return examples
+}
+
+// ExtractExamples writes each snippet of the examples to
+// <token>/<example-title>/<lang>/main.<ext> in outDir along with a
+// manifest.json listing the files.
+func ExtractExamples(outDir string, examples []Example) (*ExampleManifest, error) {
+	manifest := &ExampleManifest{Examples: []ExampleManifestEntry{}}
+	usedDirs := map[string]bool{}
+
+	for _, e := range examples {
+		tokenDir := strings.NewReplacer(":", "_", "/", "_").Replace(e.Token)
+		slug := strings.Trim(nonSlugCharsRegexp.ReplaceAllString(strings.ToLower(e.Title), "-"), "-")
+		if slug == "" {
+			slug = "example"
+		}
+
+		// Examples of the same resource can have the same title.
+		exampleDir := path.Join(tokenDir, slug)
+		for n := 2; usedDirs[exampleDir]; n++ {
+			exampleDir = path.Join(tokenDir, fmt.Sprintf("%s-%d", slug, n))
+		}
+		usedDirs[exampleDir] = true
+
+		for _, lang := range sortedKeys(e.Snippets) {
+			ext, ok := exampleFileExtensions[lang]
+			if !ok {
+				ext = "txt"
+			}
+
+			p := path.Join(exampleDir, lang, "main."+ext)
+			if err := EmitFile(outDir, p, []byte(e.Snippets[lang])); err != nil {
+				return nil, fmt.Errorf("writing example %s: %w", p, err)
+			}
+
+			manifest.Examples = append(manifest.Examples, ExampleManifestEntry{
+				Token:    e.Token,
+				Kind:     e.Kind,
+				Title:    e.Title,
+				Language: lang,
+				Path:     p,
+			})
+		}
+	}
+
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshalling the examples manifest: %w", err)
+	}
+	if err := EmitFile(outDir, "manifest.json", b); err != nil {
+		return nil, fmt.Errorf("writing the examples manifest: %w", err)
+	}
+
+	return manifest, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,319 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+)
+
+const (
+	frontMatterDelimiter = "---"
+	// packageTitlePlaceholder is replaced with the title of the package in
+	// the expected title of a page.
+	packageTitlePlaceholder = "{title}"
+)
+
+var (
+	frontMatterKeyRegexp = regexp.MustCompile(`^([A-Za-z0-9_-]+)\s*:`)
+	yamlErrorLineRegexp  = regexp.MustCompile(`line (\d+)`)
+)
+
+// FrontMatterRules maps the file name of a package docs page to the rule
+// that its front matter must follow.
+type FrontMatterRules map[string]FrontMatterRule
+
+// FrontMatterRule is the set of requirements for the front matter of a page.
+type FrontMatterRule struct {
+	// Required are the keys that must have a non-empty value.
+	Required []string `json:"required,omitempty"`
+	// Allowed maps a key to the values allowed for it. When fixing the front
+	// matter, invalid or missing values are set to the first allowed value.
+	Allowed map[string][]string `json:"allowed,omitempty"`
+	// Title is the expected title of the page, in which {title} is replaced
+	// with the title of the package. The title isn't checked if empty.
+	Title string `json:"title,omitempty"`
+}
+
+// DefaultFrontMatterRules are the rules that the pages fetched from the
+// repo of a package must follow for the registry to build.
+var DefaultFrontMatterRules = FrontMatterRules{
+	"_index.md": {
+		Required: []string{"title", "meta_desc", "layout"},
+		Allowed:  map[string][]string{"layout": {"package"}},
+		Title:    packageTitlePlaceholder,
+	},
+	"installation-configuration.md": {
+		Required: []string{"title", "meta_desc", "layout"},
+		Allowed:  map[string][]string{"layout": {"installation"}},
+		Title:    packageTitlePlaceholder + " Installation & Configuration",
+	},
+}
+
+// LoadFrontMatterRules reads the front matter rules from a YAML file.
+func LoadFrontMatterRules(rulesFile string) (FrontMatterRules, error) {
+	b, err := os.ReadFile(rulesFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading front matter rules %s: %w", rulesFile, err)
+	}
+
+	var rules FrontMatterRules
+	if err := yaml.Unmarshal(b, &rules); err != nil {
+		return nil, fmt.Errorf("unmarshalling front matter rules %s: %w", rulesFile, err)
+	}
+	return rules, nil
+}
+
+// FrontMatterOptions are the options used to validate and normalize the
+// front matter of a page.
+type FrontMatterOptions struct {
+	Rule FrontMatterRule
+	// PackageTitle is the title of the package, used for the expected title.
+	PackageTitle string
+	// Description is the description of the package. When fixing the front
+	// matter, it's used for a missing meta_desc.
+	Description string
+	// Fix rewrites the keys that don't follow the rule, and injects the
+	// missing ones, when their value can be determined.
+	Fix bool
+	// CanonicalURL is injected as the canonical_url of the page if set.
+	CanonicalURL string
+}
+
+// FrontMatterProblem is a problem with the front matter of a page.
+type FrontMatterProblem struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (p FrontMatterProblem) String() string {
+	return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+}
+
+// frontMatter is the front matter of a page, along with the line of each
+// of its top-level keys.
+type frontMatter struct {
+	lines []string
+	// start and end are the indices of the delimiter lines.
+	start, end int
+	values     map[string]interface{}
+	keyLines   map[string]int
+}
+
+// NormalizeFrontMatter validates the front matter of a page against the
+// rule in opts and, if requested, rewrites it. It returns the contents of
+// the page along with the problems that remain.
+func NormalizeFrontMatter(file string, contents []byte, opts FrontMatterOptions) ([]byte, []FrontMatterProblem) {
+	problem := func(line int, format string, a ...interface{}) FrontMatterProblem {
+		return FrontMatterProblem{File: file, Line: line, Message: fmt.Sprintf(format, a...)}
+	}
+
+	fm, p := parseFrontMatter(string(contents))
+	if p != nil {
+		p.File = file
+		if !opts.Fix || p.Message != "missing front matter" {
+			return contents, []FrontMatterProblem{*p}
+		}
+		// Pages without front matter get a new one with the injected keys.
+		fm = &frontMatter{
+			lines:    append([]string{frontMatterDelimiter, frontMatterDelimiter, ""}, strings.Split(string(contents), "\n")...),
+			start:    0,
+			end:      1,
+			values:   map[string]interface{}{},
+			keyLines: map[string]int{},
+		}
+	}
+
+	var problems []FrontMatterProblem
+	// fixes are the values of the keys to set.
+	fixes := map[string]string{}
+	keyLine := func(key string) int {
+		if line, ok := fm.keyLines[key]; ok {
+			return line
+		}
+		return fm.start + 1
+	}
+
+	if opts.Rule.Title != "" && opts.PackageTitle != "" {
+		expected := strings.ReplaceAll(opts.Rule.Title, packageTitlePlaceholder, opts.PackageTitle)
+		if title := fmt.Sprint(fm.value("title")); fm.value("title") != nil && title != expected {
+			if opts.Fix {
+				fixes["title"] = expected
+			} else {
+				problems = append(problems, problem(keyLine("title"),
+					"title %q doesn't match the expected title %q", title, expected))
+			}
+		} else if fm.value("title") == nil && opts.Fix {
+			fixes["title"] = expected
+		}
+	}
+
+	for key, allowed := range opts.Rule.Allowed {
+		v := fm.value(key)
+		if v == nil || len(allowed) == 0 || containsString(allowed, fmt.Sprint(v)) {
+			continue
+		}
+		if opts.Fix {
+			fixes[key] = allowed[0]
+		} else {
+			problems = append(problems, problem(keyLine(key), "%s %q must be one of %s", key, fmt.Sprint(v),
+				strings.Join(allowed, ", ")))
+		}
+	}
+
+	for _, key := range opts.Rule.Required {
+		if fm.value(key) != nil {
+			continue
+		}
+		if opts.Fix {
+			if _, ok := fixes[key]; ok {
+				continue
+			}
+			var v string
+			switch {
+			case key == "meta_desc":
+				v = shortDescription(opts.Description)
+			case len(opts.Rule.Allowed[key]) > 0:
+				v = opts.Rule.Allowed[key][0]
+			}
+			if v != "" {
+				fixes[key] = v
+				continue
+			}
+		}
+		problems = append(problems, problem(fm.start+1, "missing required key %q", key))
+	}
+
+	if opts.CanonicalURL != "" {
+		fixes["canonical_url"] = opts.CanonicalURL
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+
+	if len(fixes) == 0 && p == nil {
+		return contents, problems
+	}
+	return []byte(fm.rewrite(fixes, opts.Rule.Required)), problems
+}
+
+// parseFrontMatter parses the front matter at the start of a page.
+func parseFrontMatter(contents string) (*frontMatter, *FrontMatterProblem) {
+	lines := strings.Split(strings.ReplaceAll(contents, "\r\n", "\n"), "\n")
+	if len(lines) == 0 || strings.TrimSpace(strings.TrimPrefix(lines[0], "\ufeff")) != frontMatterDelimiter {
+		return nil, &FrontMatterProblem{Line: 1, Message: "missing front matter"}
+	}
+
+	fm := &frontMatter{lines: lines, start: 0, end: -1, keyLines: map[string]int{}}
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == frontMatterDelimiter {
+			fm.end = i
+			break
+		}
+		if m := frontMatterKeyRegexp.FindStringSubmatch(lines[i]); m != nil {
+			fm.keyLines[m[1]] = i + 1
+		}
+	}
+	if fm.end < 0 {
+		return nil, &FrontMatterProblem{Line: 1, Message: "unterminated front matter"}
+	}
+
+	block := strings.Join(lines[1:fm.end], "\n")
+	if err := yaml.Unmarshal([]byte(block), &fm.values); err != nil {
+		line := 1
+		if m := yamlErrorLineRegexp.FindStringSubmatch(err.Error()); m != nil {
+			// The lines of the YAML error are relative to the front matter.
+			n, _ := strconv.Atoi(m[1])
+			line = n + 1
+		}
+		return nil, &FrontMatterProblem{Line: line, Message: fmt.Sprintf("invalid front matter: %v", err)}
+	}
+	if fm.values == nil {
+		fm.values = map[string]interface{}{}
+	}
+
+	return fm, nil
+}
+
+// value returns the value of a key, or nil if the key is missing or empty.
+func (fm *frontMatter) value(key string) interface{} {
+	v, ok := fm.values[key]
+	if !ok || v == nil || fmt.Sprint(v) == "" {
+		return nil
+	}
+	return v
+}
+
+// rewrite returns the page with the keys in fixes set to their values.
+// Existing keys, including their multi-line values, are replaced in place and
+// new keys are added at the end of the front matter, the keys in order
+// first.
+func (fm *frontMatter) rewrite(fixes map[string]string, order []string) string {
+	var out []string
+	out = append(out, fm.lines[:fm.start+1]...)
+
+	written := map[string]bool{}
+	for i := fm.start + 1; i < fm.end; i++ {
+		m := frontMatterKeyRegexp.FindStringSubmatch(fm.lines[i])
+		if m == nil {
+			out = append(out, fm.lines[i])
+			continue
+		}
+		v, ok := fixes[m[1]]
+		if !ok {
+			out = append(out, fm.lines[i])
+			continue
+		}
+
+		out = append(out, yamlKeyValue(m[1], v))
+		written[m[1]] = true
+		// Skip the continuation lines of a multi-line value.
+		for i+1 < fm.end && (strings.HasPrefix(fm.lines[i+1], " ") || strings.HasPrefix(fm.lines[i+1], "\t")) {
+			i++
+		}
+	}
+
+	for _, key := range append(append([]string{}, order...), sortedKeys(fixes)...) {
+		if _, ok := fixes[key]; ok && !written[key] {
+			written[key] = true
+			out = append(out, yamlKeyValue(key, fixes[key]))
+		}
+	}
+
+	out = append(out, fm.lines[fm.end:]...)
+	return strings.Join(out, "\n")
+}
+
+// yamlKeyValue returns the YAML of a key with a string value, which is
+// quoted, or written as a block, if it can't be a plain scalar.
+func yamlKeyValue(key, value string) string {
+	b, err := yaml.Marshal(map[string]string{key: value})
+	contract.AssertNoErrorf(err, "marshalling the front matter key %s", key)
+	return strings.TrimSuffix(string(b), "\n")
+}
+
+// PackageDocsPageURL returns the URL that a page in the docs of a package is
+// served from by the registry.
+func PackageDocsPageURL(baseURL, pkgName, file string) string {
+	u := strings.TrimSuffix(baseURL, "/") + registryPackagesURLPath + pkgName + "/"
+	if dir := pageURLDir(file); dir != "" {
+		u += strings.TrimSuffix(dir, "/") + "/"
+	}
+	return u
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
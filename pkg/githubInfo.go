@@ -1,20 +1,47 @@
 package pkg
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 )
 
-func GetGitHubAPI(path string) (*http.Response, error) {
+// DefaultRequestTimeout is the default of RequestTimeout.
+const DefaultRequestTimeout = 2 * time.Minute
+
+// RequestTimeout is the timeout of each request made to GitHub and the
+// registry, including reading the response. 0 means no timeout.
+var RequestTimeout = DefaultRequestTimeout
+
+// httpClient returns the client that makes the requests, with RequestTimeout.
+func httpClient() *http.Client {
+	return &http.Client{Timeout: RequestTimeout}
+}
+
+// HTTPGet makes a GET request that is canceled when ctx is done.
+func HTTPGet(ctx context.Context, url string) (*http.Response, error) {
+	return httpRequest(ctx, http.MethodGet, url)
+}
+
+func httpRequest(ctx context.Context, method, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating request")
+	}
+	return httpClient().Do(req)
+}
+
+func GetGitHubAPI(ctx context.Context, path string) (*http.Response, error) {
 	token := os.Getenv("GITHUB_TOKEN")
 	url := fmt.Sprintf("https://api.github.com%s", path)
 
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "creating request")
 	}
@@ -27,7 +54,54 @@ func GetGitHubAPI(path string) (*http.Response, error) {
 		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
 	}
 
-	return client.Do(req)
+	return httpClient().Do(req)
+}
+
+// GetLatestVersion returns the tag of the latest GitHub release of a repo.
+func GetLatestVersion(ctx context.Context, repoSlug string) (string, error) {
+	path := fmt.Sprintf("/repos/%s/releases/latest", repoSlug)
+	resp, err := GetGitHubAPI(ctx, path)
+
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("getting latest version from https://api.github.com%s", path))
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", errors.New(fmt.Sprintf("Could not find a release at https://api.github.com%s", path))
+	}
+
+	var tag GitHubTag
+	err = json.NewDecoder(resp.Body).Decode(&tag)
+
+	if err != nil {
+		return "", errors.Wrap(err, "failure reading contents of latest tag")
+	}
+
+	return tag.Name, nil
+}
+
+// GetReleaseDate returns the date that the GitHub release of a tag of a repo
+// was published.
+func GetReleaseDate(ctx context.Context, repoSlug, tag string) (time.Time, error) {
+	path := fmt.Sprintf("/repos/%s/releases/tags/%s", strings.Trim(repoSlug, "/"), tag)
+	resp, err := GetGitHubAPI(ctx, path)
+
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, fmt.Sprintf("getting the release from https://api.github.com%s", path))
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return time.Time{}, errors.New(fmt.Sprintf("Could not find a release at https://api.github.com%s", path))
+	}
+
+	var release GitHubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return time.Time{}, errors.Wrap(err, "failure reading contents of the release")
+	}
+
+	return release.PublishedAt, nil
 }
 
 type GitHubTag struct {
@@ -52,3 +126,20 @@ type GitHubCommit struct {
 		} `json:"author"`
 	} `json:"commit"`
 }
+
+type GitHubRelease struct {
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	Body        string    `json:"body"`
+	HTMLURL     string    `json:"html_url"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+type GitHubContent struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	DownloadURL string `json:"download_url"`
+}
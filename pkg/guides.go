@@ -0,0 +1,307 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+const (
+	repoDocsDir = "docs"
+	// guidesNavDir is the sub-directory of the nav directory that the guides
+	// nav trees are written to.
+	guidesNavDir = "guides"
+)
+
+var (
+	markdownImageRegexp = regexp.MustCompile(`!\[[^\]]*\]\(([^)\s]+)`)
+	htmlImageRegexp     = regexp.MustCompile(`<img\s[^>]*src="([^"]+)"`)
+	imageExtensions     = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".webp": true}
+)
+
+// GuideNavItem is an entry of the nav tree of the guides of a package.
+type GuideNavItem struct {
+	Name string `json:"name"`
+	// Type is either guide or directory.
+	Type     string         `json:"type"`
+	Link     string         `json:"link"`
+	Children []GuideNavItem `json:"children,omitempty"`
+}
+
+// SyncDocsOptions are the options used to mirror the docs/ directory of the
+// repo of a package.
+type SyncDocsOptions struct {
+	RepoSlug string
+	// Ref is the tag, branch or commit to mirror the docs of.
+	Ref     string
+	PkgName string
+	// DocsDir is the path of the docs directory in the repo. Defaults to
+	// docs.
+	DocsDir string
+	// DocsOutDir is the directory that the pages are written to.
+	DocsOutDir string
+	// StaticOutDir is the directory that the images are copied to, under a
+	// sub-directory named after the package.
+	StaticOutDir string
+	// StaticURLPath is the URL path that StaticOutDir is served from.
+	StaticURLPath string
+	// NavOutDir is the directory that the nav tree of the guides is written
+	// to, under the guides sub-directory.
+	NavOutDir string
+}
+
+// DocsSync mirrors the docs/ directory of the repo of a package into the
+// registry.
+type DocsSync struct {
+	opts SyncDocsOptions
+	// pages are the paths, relative to the docs directory, of the Markdown
+	// pages in the repo.
+	pages []string
+	// images maps the path of the images referenced by the pages, relative
+	// to the root of the repo, to the path they are copied to, relative to
+	// the static directory.
+	images map[string]string
+}
+
+// NewDocsSync lists the docs/ directory of the repo of a package.
+func NewDocsSync(ctx context.Context, opts SyncDocsOptions) (*DocsSync, error) {
+	if opts.DocsDir == "" {
+		opts.DocsDir = repoDocsDir
+	}
+
+	contents, err := listGitHubContents(ctx, opts.RepoSlug, opts.DocsDir, opts.Ref)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &DocsSync{opts: opts, images: map[string]string{}}
+	for _, c := range contents {
+		if strings.HasSuffix(c.Path, ".md") {
+			s.pages = append(s.pages, strings.TrimPrefix(c.Path, opts.DocsDir+"/"))
+		}
+	}
+	sort.Strings(s.pages)
+	return s, nil
+}
+
+// SyncPages writes the pages of the docs directory, except the pages in
+// skip, to the docs output directory with their image links rewritten, and
+// writes the nav tree of the guides.
+func (s *DocsSync) SyncPages(ctx context.Context, skip ...string) error {
+	skipped := map[string]bool{}
+	for _, p := range skip {
+		skipped[p] = true
+	}
+
+	titles := map[string]string{}
+	for _, page := range s.pages {
+		if skipped[page] {
+			continue
+		}
+
+		glog.V(2).Infof("Syncing docs page %s of %s", page, s.opts.RepoSlug)
+		contents, err := readGitHubFile(ctx, s.opts.RepoSlug, s.opts.Ref, path.Join(s.opts.DocsDir, page))
+		if err != nil {
+			return err
+		}
+		contents = s.RewriteImageLinks(page, contents)
+
+		if err := EmitFile(s.opts.DocsOutDir, page, contents); err != nil {
+			return fmt.Errorf("writing docs page %s: %w", page, err)
+		}
+		titles[page] = pageTitle(page, contents)
+	}
+
+	if s.opts.NavOutDir == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(guidesNav(titles, ""))
+	if err != nil {
+		return fmt.Errorf("marshalling the guides nav tree: %w", err)
+	}
+	if err := EmitFile(path.Join(s.opts.NavOutDir, guidesNavDir), s.opts.PkgName+".json", b); err != nil {
+		return fmt.Errorf("writing the guides nav tree: %w", err)
+	}
+	return nil
+}
+
+// RewriteImageLinks rewrites the relative links to images in a page to the
+// path the images are copied to by CopyImages. page is the path of the page
+// relative to the docs directory.
+func (s *DocsSync) RewriteImageLinks(page string, contents []byte) []byte {
+	rewrite := func(re *regexp.Regexp, contents []byte) []byte {
+		return re.ReplaceAllFunc(contents, func(match []byte) []byte {
+			link := string(re.FindSubmatch(match)[1])
+			if urlSchemeRegexp.MatchString(link) || strings.HasPrefix(link, "/") ||
+				!imageExtensions[strings.ToLower(path.Ext(link))] {
+				return match
+			}
+
+			repoPath := path.Join(s.opts.DocsDir, path.Dir(page), link)
+			if strings.HasPrefix(repoPath, "../") {
+				glog.V(2).Infof("Not copying image %s of page %s, which is outside of the repo", link, page)
+				return match
+			}
+
+			staticPath := path.Join(s.opts.PkgName, strings.TrimPrefix(repoPath, s.opts.DocsDir+"/"))
+			s.images[repoPath] = staticPath
+			urlPath := "/" + path.Join(strings.Trim(s.opts.StaticURLPath, "/"), staticPath)
+			return []byte(strings.Replace(string(match), link, urlPath, 1))
+		})
+	}
+
+	contents = rewrite(markdownImageRegexp, contents)
+	return rewrite(htmlImageRegexp, contents)
+}
+
+// CopyImages copies the images referenced by the synced pages to the static
+// directory.
+func (s *DocsSync) CopyImages(ctx context.Context) error {
+	repoPaths := make([]string, 0, len(s.images))
+	for p := range s.images {
+		repoPaths = append(repoPaths, p)
+	}
+	sort.Strings(repoPaths)
+
+	for _, p := range repoPaths {
+		contents, err := readGitHubFile(ctx, s.opts.RepoSlug, s.opts.Ref, p)
+		if err != nil {
+			return err
+		}
+		if contents == nil {
+			fmt.Fprintf(os.Stderr, "Warning: image %s referenced by the docs of %s was not found\n", p, s.opts.RepoSlug)
+			continue
+		}
+		if err := EmitFile(s.opts.StaticOutDir, s.images[p], contents); err != nil {
+			return fmt.Errorf("writing image %s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// guidesNav returns the nav tree of the pages in dir, which are named after
+// the title of the pages. titles maps the path of each page to its title.
+// The landing page of a directory is the link of the directory itself.
+func guidesNav(titles map[string]string, dir string) []GuideNavItem {
+	var items []GuideNavItem
+	subdirs := map[string]bool{}
+	for page, title := range titles {
+		if !strings.HasPrefix(page, dir) {
+			continue
+		}
+
+		rel := strings.TrimPrefix(page, dir)
+		if i := strings.Index(rel, "/"); i >= 0 {
+			subdirs[rel[:i]] = true
+			continue
+		}
+		if rel == "_index.md" || rel == "index.md" {
+			continue
+		}
+		items = append(items, GuideNavItem{Name: title, Type: "guide", Link: pageURLDir(page) + "/"})
+	}
+
+	for subdir := range subdirs {
+		name := subdir
+		if title, ok := titles[dir+subdir+"/_index.md"]; ok {
+			name = title
+		}
+		items = append(items, GuideNavItem{
+			Name:     name,
+			Type:     "directory",
+			Link:     dir + subdir + "/",
+			Children: guidesNav(titles, dir+subdir+"/"),
+		})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		ni, nj := strings.ToLower(items[i].Name), strings.ToLower(items[j].Name)
+		if ni != nj {
+			return ni < nj
+		}
+		return items[i].Link < items[j].Link
+	})
+	return items
+}
+
+// pageTitle returns the title in the front matter of a page, or else its
+// file name.
+func pageTitle(page string, contents []byte) string {
+	if fm, _ := parseFrontMatter(string(contents)); fm != nil && fm.value("title") != nil {
+		return fmt.Sprint(fm.value("title"))
+	}
+	return strings.TrimSuffix(path.Base(page), ".md")
+}
+
+// listGitHubContents returns the files in a directory of a repo at a given
+// ref, including the files in its sub-directories. Returns no files if the
+// directory doesn't exist.
+func listGitHubContents(ctx context.Context, repoSlug, dir, ref string) ([]GitHubContent, error) {
+	resp, err := GetGitHubAPI(ctx, fmt.Sprintf("/repos/%s/contents/%s?ref=%s", repoSlug, dir, ref))
+	if err != nil {
+		return nil, fmt.Errorf("listing %s of %s: %w", dir, repoSlug, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		glog.V(2).Infof("%s doesn't exist in %s@%s, so there are no guides to sync", dir, repoSlug, ref)
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("listing %s of %s: %s", dir, repoSlug, resp.Status)
+	}
+
+	var contents []GitHubContent
+	if err := json.NewDecoder(resp.Body).Decode(&contents); err != nil {
+		return nil, fmt.Errorf("decoding the contents of %s of %s: %w", dir, repoSlug, err)
+	}
+
+	var files []GitHubContent
+	for _, c := range contents {
+		switch c.Type {
+		case "file":
+			files = append(files, c)
+		case "dir":
+			dirFiles, err := listGitHubContents(ctx, repoSlug, c.Path, ref)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, dirFiles...)
+		}
+	}
+	return files, nil
+}
+
+// readGitHubFile downloads a file from a repo at a given ref. Returns nil if
+// the file doesn't exist.
+func readGitHubFile(ctx context.Context, repoSlug, ref, filePath string) ([]byte, error) {
+	url := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s", repoSlug, ref, filePath)
+	resp, err := HTTPGet(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+
+	contents, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", url, err)
+	}
+	return contents, nil
+}
@@ -0,0 +1,127 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	docsgen "github.com/pulumi/pulumi/pkg/v3/codegen/docs"
+)
+
+// DocsFormat is the format that the API docs are written in.
+type DocsFormat string
+
+const (
+	// DocsFormatMarkdown is the Hugo-flavored Markdown used by the registry.
+	DocsFormatMarkdown DocsFormat = "markdown"
+	// DocsFormatHTML is a standalone static site.
+	DocsFormatHTML DocsFormat = "html"
+)
+
+// HTMLSite writes the API docs of packages as a standalone static site and
+// collects the packages for the index page of the site.
+type HTMLSite struct {
+	packages []htmlSitePackage
+}
+
+type htmlSitePackage struct {
+	Meta PackageMeta
+	// DocsDir is the directory of the HTML docs of the package.
+	DocsDir string
+}
+
+// NewHTMLSite returns an empty site.
+func NewHTMLSite() *HTMLSite {
+	return &HTMLSite{}
+}
+
+// ConvertPackage renders the API docs of a package, each page as
+// <dir>/index.html. The landing page of the API docs shows the package's
+// metadata.
+func (s *HTMLSite) ConvertPackage(meta PackageMeta, outDir string, files map[string][]byte,
+	nav []docsgen.PackageTreeItem) (map[string][]byte, error) {
+	script, err := navScript(nav)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string][]byte{navScriptFile: script}
+
+	for f, contents := range files {
+		dir := path.Dir(f)
+		// The pages link to each other relative to their own URL, so the
+		// links to the root of the docs are too.
+		root := relativeRoot(f)
+		page, err := renderDocsPage(meta.Name, f, contents, root, root)
+		if err != nil {
+			return nil, err
+		}
+		if dir == "." {
+			page.Meta = &meta
+		}
+
+		var buf bytes.Buffer
+		if err := docsPageTemplate.Execute(&buf, page); err != nil {
+			return nil, fmt.Errorf("rendering %s: %w", f, err)
+		}
+		out[path.Join(dir, "index.html")] = buf.Bytes()
+	}
+
+	s.packages = append(s.packages, htmlSitePackage{Meta: meta, DocsDir: outDir})
+	return out, nil
+}
+
+// Write writes the index page of the site, listing the packages added to it,
+// to outDir. The index page is only written if outDir is above the docs of
+// all of the packages, so that it doesn't replace the landing page of a
+// package whose docs are written to outDir itself.
+func (s *HTMLSite) Write(outDir string) error {
+	type indexEntry struct {
+		Meta PackageMeta
+		URL  string
+	}
+	var entries []indexEntry
+	for _, p := range s.packages {
+		rel, err := filepath.Rel(outDir, p.DocsDir)
+		if err != nil {
+			return fmt.Errorf("getting the path of the docs of %s: %w", p.Meta.Name, err)
+		}
+		if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			glog.V(2).Infof("Skipping the index page of the site since %s isn't above the docs of %s", outDir, p.Meta.Name)
+			return nil
+		}
+		entries = append(entries, indexEntry{Meta: p.Meta, URL: filepath.ToSlash(rel) + "/"})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Meta.Title < entries[j].Meta.Title
+	})
+
+	var buf bytes.Buffer
+	if err := siteIndexTemplate.Execute(&buf, entries); err != nil {
+		return fmt.Errorf("rendering the index page: %w", err)
+	}
+	return EmitFile(outDir, "index.html", buf.Bytes())
+}
+
+var siteIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Packages</title>
+<style>` + docsPageStyle + `</style>
+</head>
+<body>
+<main>
+<h1>Packages</h1>
+<table>
+<tr><th>Package</th><th>Description</th><th>Version</th></tr>
+{{ range . }}<tr><td><a href="{{ .URL }}">{{ .Meta.Title }}</a></td><td>{{ .Meta.Description }}</td><td>{{ .Meta.Version }}</td></tr>
+{{ end }}</table>
+</main>
+</body>
+</html>
+`))
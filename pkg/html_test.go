@@ -0,0 +1,60 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHTMLSiteWriteKeepsPackageLandingPage(t *testing.T) {
+	outDir := t.TempDir()
+	site := NewHTMLSite()
+	files, err := site.ConvertPackage(PackageMeta{Name: "random", Title: "Random"}, outDir,
+		map[string][]byte{"_index.md": []byte("# Random\n")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	landingPage := files["index.html"]
+	if err := EmitFile(outDir, "index.html", landingPage); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := site.Write(outDir); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(outDir, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != string(landingPage) {
+		t.Errorf("the landing page of the package was replaced by the index page of the site:\n%s", b)
+	}
+}
+
+func TestHTMLSiteWriteListsPackages(t *testing.T) {
+	outDir := t.TempDir()
+	site := NewHTMLSite()
+	for _, name := range []string{"random", "tls"} {
+		_, err := site.ConvertPackage(PackageMeta{Name: name, Title: name}, filepath.Join(outDir, name, "api-docs"),
+			map[string][]byte{"_index.md": []byte("# " + name + "\n")}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := site.Write(outDir); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(outDir, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, url := range []string{`href="random/api-docs/"`, `href="tls/api-docs/"`} {
+		if !strings.Contains(string(b), url) {
+			t.Errorf("expected the index page to link to %s:\n%s", url, b)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/dotnet"
+	go_gen "github.com/pulumi/pulumi/pkg/v3/codegen/go"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/nodejs"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/python"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// javaPackageInfo is the subset of the java language section of a schema
+// needed to reference the package's SDK.
+type javaPackageInfo struct {
+	BasePackage string `json:"basePackage,omitempty"`
+}
+
+// sdkInstallation is the instructions to install the SDK of a package in a
+// single language.
+type sdkInstallation struct {
+	Language    string
+	PackageName string
+	PackageURL  string
+	// CodeLanguage is the language of the code fence of Command.
+	CodeLanguage string
+	Command      string
+	// ChooserLanguage is the language of the language chooser that the
+	// Import snippet is shown for.
+	ChooserLanguage string
+	Import          string
+}
+
+// GenerateInstallationConfiguration generates an installation and
+// configuration page for a package from its schema. It's used as a fallback
+// for packages whose repo doesn't have a docs/installation-configuration.md.
+func GenerateInstallationConfiguration(spec *pschema.PackageSpec, title string) ([]byte, error) {
+	if title == "" {
+		title = spec.Name
+	}
+
+	installations, err := getSDKInstallations(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	frontMatter, err := yaml.Marshal(map[string]string{
+		"title":     title + " Installation & Configuration",
+		"meta_desc": fmt.Sprintf("Information on how to install the %s provider.", title),
+		"layout":    "installation",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshalling the front matter of the installation page: %w", err)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "---\n%s---\n", frontMatter)
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "<!-- This page was generated from the package's schema because its repository doesn't have a "+
+		"docs/installation-configuration.md. -->")
+	fmt.Fprintln(&b)
+
+	fmt.Fprintln(&b, "## Installation")
+	fmt.Fprintln(&b)
+	if len(installations) == 0 {
+		fmt.Fprintf(&b, "The %s provider doesn't have any published SDKs.\n\n", title)
+	} else {
+		fmt.Fprintf(&b, "The %s provider is available as a package in the following Pulumi languages:\n\n", title)
+		for _, i := range installations {
+			fmt.Fprintf(&b, "* %s: [`%s`](%s)\n", i.Language, i.PackageName, i.PackageURL)
+		}
+		for _, i := range installations {
+			fmt.Fprintf(&b, "\n### %s\n\n```%s\n%s\n```\n", i.Language, i.CodeLanguage, i.Command)
+		}
+		fmt.Fprintln(&b)
+	}
+
+	fmt.Fprintln(&b, "### Provider plugin")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "The provider's plugin is installed automatically when the SDK is used by a program. It can also "+
+		"be installed manually with:")
+	fmt.Fprintln(&b)
+	fmt.Fprintf(&b, "```bash\n%s\n```\n\n", pluginInstallCommand(spec))
+
+	fmt.Fprintln(&b, "## Configuration")
+	fmt.Fprintln(&b)
+	writeConfigurationTable(&b, spec)
+
+	return b.Bytes(), nil
+}
+
+// getSDKInstallations returns the instructions to install the SDK of each
+// language in the language section of the schema.
+func getSDKInstallations(spec *pschema.PackageSpec) ([]sdkInstallation, error) {
+	var installations []sdkInstallation
+
+	if raw, ok := spec.Language["nodejs"]; ok {
+		var info nodejs.NodePackageInfo
+		if err := json.Unmarshal(raw, &info); err != nil {
+			return nil, fmt.Errorf("reading the nodejs language section: %w", err)
+		}
+		name := info.PackageName
+		if name == "" {
+			name = fmt.Sprintf("@pulumi/%s", spec.Name)
+		}
+		installations = append(installations, sdkInstallation{
+			Language:     "Node.js (JavaScript/TypeScript)",
+			PackageName:  name,
+			PackageURL:   fmt.Sprintf("https://www.npmjs.com/package/%s", name),
+			CodeLanguage: "bash",
+			Command:      fmt.Sprintf("npm install %s", name),
+
+			ChooserLanguage: "typescript",
+			Import:          fmt.Sprintf("import * as %s from \"%s\";", importAlias(spec.Name), name),
+		})
+	}
+
+	if raw, ok := spec.Language["python"]; ok {
+		var info python.PackageInfo
+		if err := json.Unmarshal(raw, &info); err != nil {
+			return nil, fmt.Errorf("reading the python language section: %w", err)
+		}
+		name := info.PackageName
+		if name == "" {
+			name = "pulumi_" + strings.ReplaceAll(spec.Name, "-", "_")
+		}
+		// The name of the distribution on PyPI uses dashes.
+		distribution := strings.ReplaceAll(name, "_", "-")
+		installations = append(installations, sdkInstallation{
+			Language:     "Python",
+			PackageName:  distribution,
+			PackageURL:   fmt.Sprintf("https://pypi.org/project/%s/", distribution),
+			CodeLanguage: "bash",
+			Command:      fmt.Sprintf("pip install %s", distribution),
+
+			ChooserLanguage: "python",
+			Import:          fmt.Sprintf("import %s as %s", name, importAlias(spec.Name)),
+		})
+	}
+
+	if raw, ok := spec.Language["go"]; ok {
+		var info go_gen.GoPackageInfo
+		if err := json.Unmarshal(raw, &info); err != nil {
+			return nil, fmt.Errorf("reading the go language section: %w", err)
+		}
+		// The import path of the Go SDK can't be derived from the name of the
+		// package, so it's only documented if the schema specifies it.
+		if info.ImportBasePath != "" {
+			installations = append(installations, sdkInstallation{
+				Language:     "Go",
+				PackageName:  info.ImportBasePath,
+				PackageURL:   fmt.Sprintf("https://pkg.go.dev/%s", info.ImportBasePath),
+				CodeLanguage: "bash",
+				Command:      fmt.Sprintf("go get %s", info.ImportBasePath),
+
+				ChooserLanguage: "go",
+				Import:          fmt.Sprintf("import \"%s\"", info.ImportBasePath),
+			})
+		}
+	}
+
+	if raw, ok := spec.Language["csharp"]; ok {
+		var info dotnet.CSharpPackageInfo
+		if err := json.Unmarshal(raw, &info); err != nil {
+			return nil, fmt.Errorf("reading the csharp language section: %w", err)
+		}
+		namespace, ok := info.Namespaces[spec.Name]
+		if !ok {
+			namespace = dotnet.Title(spec.Name)
+		}
+		name := fmt.Sprintf("%s.%s", info.GetRootNamespace(), namespace)
+		installations = append(installations, sdkInstallation{
+			Language:     ".NET",
+			PackageName:  name,
+			PackageURL:   fmt.Sprintf("https://www.nuget.org/packages/%s", name),
+			CodeLanguage: "bash",
+			Command:      fmt.Sprintf("dotnet add package %s", name),
+
+			ChooserLanguage: "csharp",
+			Import:          fmt.Sprintf("using %s;", name),
+		})
+	}
+
+	if raw, ok := spec.Language["java"]; ok {
+		var info javaPackageInfo
+		if err := json.Unmarshal(raw, &info); err != nil {
+			return nil, fmt.Errorf("reading the java language section: %w", err)
+		}
+		group := info.BasePackage
+		if group == "" {
+			group = "com.pulumi"
+		}
+		installations = append(installations, sdkInstallation{
+			Language:     "Java",
+			PackageName:  fmt.Sprintf("%s/%s", group, spec.Name),
+			PackageURL:   fmt.Sprintf("https://central.sonatype.com/artifact/%s/%s", group, spec.Name),
+			CodeLanguage: "groovy",
+			Command: fmt.Sprintf("dependencies {\n    implementation \"%s:%s:%s\"\n}",
+				group, spec.Name, strings.TrimPrefix(spec.Version, "v")),
+
+			ChooserLanguage: "java",
+			Import:          fmt.Sprintf("import %s.%s.*;", group, strings.ReplaceAll(spec.Name, "-", "")),
+		})
+	}
+
+	return installations, nil
+}
+
+// importAlias returns the identifier that the SDK of a package is imported
+// as in code snippets.
+func importAlias(pkgName string) string {
+	return strings.ReplaceAll(pkgName, "-", "_")
+}
+
+func pluginInstallCommand(spec *pschema.PackageSpec) string {
+	command := fmt.Sprintf("pulumi plugin install resource %s", spec.Name)
+	if spec.Version != "" {
+		command += " " + strings.TrimPrefix(spec.Version, "v")
+	}
+	if spec.PluginDownloadURL != "" {
+		command += " --server " + spec.PluginDownloadURL
+	}
+	return command
+}
+
+// writeConfigurationTable writes the table of the configuration variables
+// of the provider. The defaults, environment variables and secrecy of a
+// variable are taken from the provider's input property of the same name if
+// the variable doesn't specify them.
+func writeConfigurationTable(b *bytes.Buffer, spec *pschema.PackageSpec) {
+	variables := spec.Config.Variables
+	if len(variables) == 0 {
+		fmt.Fprintln(b, "The provider doesn't have any configuration options.")
+		return
+	}
+
+	required := map[string]bool{}
+	for _, name := range spec.Config.Required {
+		required[name] = true
+	}
+
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(b, "The following configuration options are available and can be set with "+
+		"`pulumi config set %s:<option> <value>`, adding `--secret` for secret values:\n\n", spec.Name)
+	fmt.Fprintln(b, "| Option | Type | Required | Default | Environment variables | Secret | Description |")
+	fmt.Fprintln(b, "| --- | --- | --- | --- | --- | --- | --- |")
+	for _, name := range names {
+		v := variables[name]
+		if p, ok := spec.Provider.InputProperties[name]; ok {
+			if v.Default == nil {
+				v.Default = p.Default
+			}
+			if v.DefaultInfo == nil {
+				v.DefaultInfo = p.DefaultInfo
+			}
+			v.Secret = v.Secret || p.Secret
+		}
+
+		def := ""
+		if v.Default != nil {
+			def = fmt.Sprintf("`%v`", v.Default)
+		}
+		var envVars []string
+		if v.DefaultInfo != nil {
+			for _, env := range v.DefaultInfo.Environment {
+				envVars = append(envVars, fmt.Sprintf("`%s`", env))
+			}
+		}
+
+		fmt.Fprintf(b, "| `%s` | %s | %s | %s | %s | %s | %s |\n",
+			name,
+			tableCell(typeSpecName(v.TypeSpec)),
+			yesNo(required[name]),
+			def,
+			strings.Join(envVars, ", "),
+			yesNo(v.Secret),
+			tableCell(shortDescription(v.Description)))
+	}
+}
+
+// typeSpecName returns a readable name for the type of a property.
+func typeSpecName(t pschema.TypeSpec) string {
+	switch {
+	case t.Ref != "":
+		return tokenName(t.Ref)
+	case t.Type == "array" && t.Items != nil:
+		return fmt.Sprintf("List<%s>", typeSpecName(*t.Items))
+	case t.Type == "object" && t.AdditionalProperties != nil:
+		return fmt.Sprintf("Map<%s>", typeSpecName(*t.AdditionalProperties))
+	case t.Type != "":
+		return t.Type
+	default:
+		return "any"
+	}
+}
+
+func tableCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "Yes"
+	}
+	return "No"
+}
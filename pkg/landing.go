@@ -0,0 +1,130 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// maxLandingPageModules is the maximum number of modules linked to from a
+// generated landing page. Only the API docs root is linked to for packages
+// with more modules.
+const maxLandingPageModules = 20
+
+// GenerateLandingPage generates the _index.md landing page of a package
+// from its schema. It's used as a fallback for packages whose repo doesn't
+// have a docs/_index.md.
+func GenerateLandingPage(spec *pschema.PackageSpec, title string) ([]byte, error) {
+	if title == "" {
+		title = spec.Name
+	}
+
+	installations, err := getSDKInstallations(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	metaDesc := shortDescription(spec.Description)
+	if metaDesc == "" {
+		metaDesc = fmt.Sprintf("Learn how to use the %s package with Pulumi.", title)
+	}
+
+	frontMatter, err := yaml.Marshal(map[string]string{
+		"title":     title,
+		"meta_desc": metaDesc,
+		"layout":    "package",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshalling the front matter of the landing page: %w", err)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "---\n%s---\n", frontMatter)
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "<!-- This page was generated from the package's schema because its repository doesn't have a "+
+		"docs/_index.md. -->")
+	fmt.Fprintln(&b)
+
+	if description := strings.TrimSpace(spec.Description); description != "" {
+		fmt.Fprintf(&b, "%s\n\n", description)
+	} else {
+		fmt.Fprintf(&b, "The %s package lets you manage %s resources with Pulumi.\n\n", title, title)
+	}
+
+	if len(installations) > 0 {
+		fmt.Fprintln(&b, "## Quick start")
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, "Install the SDK of your language and import it into your Pulumi program:")
+		fmt.Fprintln(&b)
+
+		var chooserLanguages []string
+		for _, i := range installations {
+			chooserLanguages = append(chooserLanguages, i.ChooserLanguage)
+		}
+		fmt.Fprintf(&b, "{{< chooser language \"%s\" >}}\n", strings.Join(chooserLanguages, ","))
+		for _, i := range installations {
+			fmt.Fprintf(&b, "{{%% choosable language %s %%}}\n\n", i.ChooserLanguage)
+			fmt.Fprintf(&b, "```%s\n%s\n```\n\n", i.CodeLanguage, i.Command)
+			fmt.Fprintf(&b, "```%s\n%s\n```\n\n", i.ChooserLanguage, i.Import)
+			fmt.Fprintln(&b, "{{% /choosable %}}")
+		}
+		fmt.Fprintln(&b, "{{< /chooser >}}")
+		fmt.Fprintln(&b)
+	}
+
+	fmt.Fprintln(&b, "## Documentation")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "* [Installation & Configuration](installation-configuration/)")
+	fmt.Fprintln(&b, "* [API Docs](api-docs/)")
+	if modules := specModules(spec); len(modules) <= maxLandingPageModules {
+		for _, m := range modules {
+			fmt.Fprintf(&b, "  * [%s](api-docs/%s/)\n", m, strings.ToLower(m))
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+// specModules returns the sorted names of the modules of the resources and
+// functions in a schema, excluding the index module.
+func specModules(spec *pschema.PackageSpec) []string {
+	moduleFormat := regexp.MustCompile("(.*)")
+	if spec.Meta != nil && spec.Meta.ModuleFormat != "" {
+		if re, err := regexp.Compile(spec.Meta.ModuleFormat); err == nil {
+			moduleFormat = re
+		}
+	}
+
+	seen := map[string]bool{}
+	addModule := func(token string) {
+		components := strings.Split(token, ":")
+		if len(components) != 3 {
+			return
+		}
+		module := components[1]
+		if m := moduleFormat.FindStringSubmatch(module); len(m) > 1 {
+			module = m[1]
+		}
+		if module != "" && module != "index" {
+			seen[module] = true
+		}
+	}
+	for token := range spec.Resources {
+		addModule(token)
+	}
+	for token := range spec.Functions {
+		addModule(token)
+	}
+
+	modules := make([]string, 0, len(seen))
+	for m := range seen {
+		modules = append(modules, m)
+	}
+	sort.Strings(modules)
+	return modules
+}
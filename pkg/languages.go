@@ -0,0 +1,108 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// docsLanguageIdentifiers maps the languages of a package's schema to the
+// identifiers used for them by the language choosers of the generated docs.
+var docsLanguageIdentifiers = map[string][]string{
+	"nodejs": {"nodejs", "javascript", "typescript"},
+	"python": {"python"},
+	"go":     {"go"},
+	"csharp": {"csharp"},
+	"java":   {"java"},
+	"yaml":   {"yaml"},
+}
+
+// languageAliases maps alternative names of the languages accepted by the
+// --languages flag to the name used in the schema.
+var languageAliases = map[string]string{
+	"typescript": "nodejs",
+	"javascript": "nodejs",
+	"dotnet":     "csharp",
+}
+
+var (
+	languageChooserRegexp   = regexp.MustCompile(`<pulumi-chooser type="language" options="([^"]*)">`)
+	languageChoosableRegexp = regexp.MustCompile(`(?s)<pulumi-choosable type="language" values="([^"]*)">.*?</pulumi-choosable>`)
+	emptyDivRegexp          = regexp.MustCompile(`<div>\s*</div>\n?`)
+)
+
+// resolveDocsLanguages returns the identifiers of the languages to render
+// in the docs. If no languages are requested, the languages declared in the
+// language section of the schema are used, along with YAML, which doesn't
+// need an SDK. Returns nil if all languages should be rendered, which is the
+// case if the schema doesn't declare any of them either.
+func resolveDocsLanguages(requested []string, spec *pschema.PackageSpec) (map[string]bool, error) {
+	languages := requested
+	if len(languages) == 0 {
+		for lang := range spec.Language {
+			if _, ok := docsLanguageIdentifiers[lang]; ok && lang != "yaml" {
+				languages = append(languages, lang)
+			}
+		}
+		if len(languages) == 0 {
+			return nil, nil
+		}
+		languages = append(languages, "yaml")
+		sort.Strings(languages)
+	}
+
+	identifiers := map[string]bool{}
+	for _, lang := range languages {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if alias, ok := languageAliases[lang]; ok {
+			lang = alias
+		}
+
+		ids, ok := docsLanguageIdentifiers[lang]
+		if !ok {
+			return nil, fmt.Errorf("unsupported language %q", lang)
+		}
+		for _, id := range ids {
+			identifiers[id] = true
+		}
+	}
+
+	return identifiers, nil
+}
+
+// filterLanguages removes the content of the languages that aren't in
+// allowed from the language choosers of a generated page.
+func filterLanguages(contents []byte, allowed map[string]bool) []byte {
+	filterValues := func(values string) []string {
+		var kept []string
+		for _, v := range strings.Split(values, ",") {
+			if allowed[strings.TrimSpace(v)] {
+				kept = append(kept, v)
+			}
+		}
+		return kept
+	}
+
+	contents = languageChooserRegexp.ReplaceAllFunc(contents, func(chooser []byte) []byte {
+		options := languageChooserRegexp.FindSubmatch(chooser)[1]
+		return []byte(fmt.Sprintf(`<pulumi-chooser type="language" options="%s">`,
+			strings.Join(filterValues(string(options)), ",")))
+	})
+
+	contents = languageChoosableRegexp.ReplaceAllFunc(contents, func(choosable []byte) []byte {
+		values := string(languageChoosableRegexp.FindSubmatch(choosable)[1])
+		kept := filterValues(values)
+		if len(kept) == 0 {
+			return nil
+		}
+
+		oldTag := fmt.Sprintf(`values="%s">`, values)
+		newTag := fmt.Sprintf(`values="%s">`, strings.Join(kept, ","))
+		return []byte(strings.Replace(string(choosable), oldTag, newTag, 1))
+	})
+
+	return emptyDivRegexp.ReplaceAll(contents, nil)
+}
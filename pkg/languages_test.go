@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+func TestResolveDocsLanguages(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested []string
+		schema    []string
+		expected  []string
+	}{
+		{
+			name:     "schema languages and yaml by default",
+			schema:   []string{"nodejs", "go"},
+			expected: []string{"go", "javascript", "nodejs", "typescript", "yaml"},
+		},
+		{
+			name:     "unknown schema languages are ignored",
+			schema:   []string{"csharp", "terraform"},
+			expected: []string{"csharp", "yaml"},
+		},
+		{
+			name:     "all languages without schema languages",
+			schema:   []string{"terraform"},
+			expected: nil,
+		},
+		{
+			name:      "requested languages override the schema",
+			requested: []string{"TypeScript", " dotnet "},
+			schema:    []string{"python"},
+			expected:  []string{"csharp", "javascript", "nodejs", "typescript"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &pschema.PackageSpec{Language: map[string]pschema.RawMessage{}}
+			for _, lang := range tt.schema {
+				spec.Language[lang] = pschema.RawMessage(`{}`)
+			}
+
+			identifiers, err := resolveDocsLanguages(tt.requested, spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var actual []string
+			for id := range identifiers {
+				actual = append(actual, id)
+			}
+			sort.Strings(actual)
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestResolveDocsLanguagesRejectsUnknownLanguages(t *testing.T) {
+	if _, err := resolveDocsLanguages([]string{"cobol"}, &pschema.PackageSpec{}); err == nil {
+		t.Error("expected an unsupported language to be rejected")
+	}
+}
+
+func TestFilterLanguages(t *testing.T) {
+	page := `<div><pulumi-chooser type="language" options="typescript,python,go,csharp,java,yaml"></pulumi-chooser></div>
+<div><pulumi-choosable type="language" values="javascript,typescript">
+ts
+</pulumi-choosable></div>
+<div><pulumi-choosable type="language" values="python">
+py
+</pulumi-choosable></div>
+<div><pulumi-choosable type="language" values="yaml">
+yaml
+</pulumi-choosable></div>
+`
+	expected := `<div><pulumi-chooser type="language" options="typescript,yaml"></pulumi-chooser></div>
+<div><pulumi-choosable type="language" values="javascript,typescript">
+ts
+</pulumi-choosable></div>
+<div><pulumi-choosable type="language" values="yaml">
+yaml
+</pulumi-choosable></div>
+`
+
+	spec := &pschema.PackageSpec{Language: map[string]pschema.RawMessage{"nodejs": pschema.RawMessage(`{}`)}}
+	allowed, err := resolveDocsLanguages(nil, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := string(filterLanguages([]byte(page), allowed)); actual != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
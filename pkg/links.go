@@ -0,0 +1,316 @@
+package pkg
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+// registryPackagesURLPath is the URL path that the docs of the packages are
+// served from by the registry.
+const registryPackagesURLPath = "/registry/packages/"
+
+var (
+	markdownLinkRegexp    = regexp.MustCompile(`\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	htmlLinkRegexp        = regexp.MustCompile(`href="([^"]*)"`)
+	htmlAnchorRegexp      = regexp.MustCompile(`(?:id|name)="([^"]+)"`)
+	headingAnchorRegexp   = regexp.MustCompile(`^#{1,6}\s+(.*?)\s*(?:\{#([^}]+)\})?\s*$`)
+	urlSchemeRegexp       = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+	nonHeadingAnchorChars = regexp.MustCompile(`[^\p{L}\p{N}\s_-]`)
+)
+
+// BrokenLink is a link in a docs page whose target page or anchor doesn't
+// exist.
+type BrokenLink struct {
+	// File is the path of the page, including the checked directory.
+	File   string
+	Line   int
+	Link   string
+	Reason string
+}
+
+func (l BrokenLink) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", l.File, l.Line, l.Link, l.Reason)
+}
+
+// LinkChecker checks the internal links of the Markdown pages of generated
+// docs.
+type LinkChecker struct {
+	// RegistryDocsDir is the directory that the docs of the packages are
+	// served from under /registry/packages/, e.g. content/registry/packages.
+	// Links to /registry/packages/ aren't checked if it's empty.
+	RegistryDocsDir string
+
+	// anchors maps the path of a page to the anchors defined in it, or nil
+	// if the page doesn't exist.
+	anchors map[string]map[string]bool
+}
+
+// NewLinkChecker returns a link checker resolving the registry-absolute
+// links against registryDocsDir.
+func NewLinkChecker(registryDocsDir string) *LinkChecker {
+	return &LinkChecker{
+		RegistryDocsDir: registryDocsDir,
+		anchors:         map[string]map[string]bool{},
+	}
+}
+
+// CheckLinks checks the links of the pages in each of dirs and returns an
+// error listing the broken links, if any.
+func CheckLinks(registryDocsDir string, dirs ...string) error {
+	checker := NewLinkChecker(registryDocsDir)
+
+	var broken []string
+	for _, dir := range dirs {
+		glog.V(2).Infof("Checking links in %s", dir)
+		dirBroken, err := checker.CheckDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, b := range dirBroken {
+			broken = append(broken, b.String())
+		}
+	}
+
+	if len(broken) > 0 {
+		return fmt.Errorf("found %d broken links:\n  %s", len(broken), strings.Join(broken, "\n  "))
+	}
+	return nil
+}
+
+// CheckDir checks the links of every Markdown page in dir. Relative links
+// and anchors are resolved against the pages in dir, as they would be once
+// the pages are served by Hugo. Links to other sites and to pages outside of
+// the registry's packages aren't checked.
+func (c *LinkChecker) CheckDir(dir string) ([]BrokenLink, error) {
+	var broken []BrokenLink
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(p, ".md") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		pageBroken, err := c.checkPage(dir, filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		broken = append(broken, pageBroken...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("checking links in %s: %w", dir, err)
+	}
+
+	sort.SliceStable(broken, func(i, j int) bool {
+		if broken[i].File != broken[j].File {
+			return broken[i].File < broken[j].File
+		}
+		return broken[i].Line < broken[j].Line
+	})
+	return broken, nil
+}
+
+// checkPage checks the links of the page at the relative path file in dir.
+func (c *LinkChecker) checkPage(dir, file string) ([]BrokenLink, error) {
+	f, err := os.Open(filepath.Join(dir, filepath.FromSlash(file)))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var broken []BrokenLink
+	inCodeBlock := false
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if strings.HasPrefix(strings.TrimSpace(line), codeFence) {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+
+		var links []string
+		for _, m := range markdownLinkRegexp.FindAllStringSubmatch(line, -1) {
+			links = append(links, m[1])
+		}
+		for _, m := range htmlLinkRegexp.FindAllStringSubmatch(line, -1) {
+			links = append(links, m[1])
+		}
+
+		for _, link := range links {
+			reason, err := c.checkLink(dir, file, link)
+			if err != nil {
+				return nil, err
+			}
+			if reason != "" {
+				broken = append(broken, BrokenLink{
+					File:   filepath.Join(dir, filepath.FromSlash(file)),
+					Line:   lineNum,
+					Link:   link,
+					Reason: reason,
+				})
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return broken, nil
+}
+
+// checkLink returns the reason the link in the page at the relative path
+// file in dir is broken, or an empty string if it isn't or can't be checked.
+func (c *LinkChecker) checkLink(dir, file, link string) (string, error) {
+	if link == "" || urlSchemeRegexp.MatchString(link) || strings.HasPrefix(link, "//") ||
+		strings.Contains(link, "{{") {
+		return "", nil
+	}
+
+	target, anchor := link, ""
+	if i := strings.Index(target, "#"); i >= 0 {
+		target, anchor = target[:i], target[i+1:]
+	}
+	if i := strings.Index(target, "?"); i >= 0 {
+		target = target[:i]
+	}
+
+	var root, targetPath string
+	switch {
+	case target == "":
+		root, targetPath = dir, file
+	case strings.HasPrefix(target, registryPackagesURLPath):
+		if c.RegistryDocsDir == "" {
+			return "", nil
+		}
+		root = c.RegistryDocsDir
+		targetPath = resolveDocsPage(root, strings.TrimPrefix(target, registryPackagesURLPath))
+	case strings.HasPrefix(target, "/"):
+		// The page isn't part of the docs of a package.
+		return "", nil
+	default:
+		resolved := path.Join(pageURLDir(file), target)
+		if resolved == ".." || strings.HasPrefix(resolved, "../") {
+			glog.V(2).Infof("Not checking link %s in %s, which is outside of %s", link, file, dir)
+			return "", nil
+		}
+		root = dir
+		targetPath = resolveDocsPage(root, resolved)
+	}
+
+	if targetPath == "" {
+		return "page not found", nil
+	}
+	if anchor == "" {
+		return "", nil
+	}
+
+	anchors, err := c.pageAnchors(filepath.Join(root, filepath.FromSlash(targetPath)))
+	if err != nil {
+		return "", err
+	}
+	if !anchors[anchor] {
+		return fmt.Sprintf("anchor #%s not found in %s", anchor, targetPath), nil
+	}
+	return "", nil
+}
+
+// pageAnchors returns the anchors defined by the id and name attributes and
+// the headings of a page.
+func (c *LinkChecker) pageAnchors(p string) (map[string]bool, error) {
+	if anchors, ok := c.anchors[p]; ok {
+		return anchors, nil
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	anchors := map[string]bool{}
+	inCodeBlock := false
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(strings.TrimSpace(line), codeFence) {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+
+		for _, m := range htmlAnchorRegexp.FindAllStringSubmatch(line, -1) {
+			anchors[m[1]] = true
+		}
+		if m := headingAnchorRegexp.FindStringSubmatch(line); m != nil {
+			if m[2] != "" {
+				anchors[m[2]] = true
+			} else {
+				anchors[headingAnchor(m[1])] = true
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	c.anchors[p] = anchors
+	return anchors, nil
+}
+
+// headingAnchor returns the anchor that Hugo generates for a heading.
+func headingAnchor(heading string) string {
+	anchor := nonHeadingAnchorChars.ReplaceAllString(strings.ToLower(heading), "")
+	return strings.Join(strings.Fields(anchor), "-")
+}
+
+// pageURLDir returns the directory, relative to the root of the docs, that
+// Hugo serves a page from and that the relative links of the page are
+// resolved against.
+func pageURLDir(file string) string {
+	dir, base := path.Split(file)
+	if base == "_index.md" || base == "index.md" {
+		return dir
+	}
+	return path.Join(dir, strings.TrimSuffix(base, ".md"))
+}
+
+// resolveDocsPage returns the path, relative to root, of the page served at
+// the URL path p relative to root, or an empty string if there's no such
+// page.
+func resolveDocsPage(root, p string) string {
+	p = strings.Trim(p, "/")
+
+	var candidates []string
+	if strings.HasSuffix(p, ".md") {
+		candidates = []string{p}
+	} else {
+		candidates = []string{path.Join(p, "_index.md"), path.Join(p, "index.md"), p + ".md"}
+	}
+	for _, candidate := range candidates {
+		info, err := os.Stat(filepath.Join(root, filepath.FromSlash(candidate)))
+		if err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
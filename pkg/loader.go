@@ -0,0 +1,323 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/golang/glog"
+	"github.com/pulumi/pulumi/pkg/v3/codegen"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// SchemaLoaderOptions controls where a SchemaLoader finds the packages
+// referenced by a schema.
+type SchemaLoaderOptions struct {
+	// RegistryPackagesPath is the directory of the registry's package
+	// metadata files, used to find the repo and schema file of a referenced
+	// package. Packages without metadata are assumed to be in
+	// pulumi/pulumi-<name> at the default schema path.
+	RegistryPackagesPath string
+	// CacheDir is the directory that downloaded schemas are cached in. The
+	// schemas aren't cached if empty.
+	CacheDir string
+}
+
+// SchemaLoader is a schema.Loader that loads the packages referenced by a
+// schema, e.g. /aws/v5.0.0/schema.json, at the referenced versions. The
+// schemas are read from the local plugin cache or the schema cache if they
+// are there, and otherwise downloaded from the package's repo.
+type SchemaLoader struct {
+	// ctx cancels the downloads of the schemas, since schema.Loader doesn't
+	// take a context.
+	ctx      context.Context
+	opts     SchemaLoaderOptions
+	metadata map[string]PackageMeta
+	// packages are the packages loaded so far, keyed by name@version.
+	packages map[string]*pschema.Package
+}
+
+// NewSchemaLoader returns a SchemaLoader that uses the package metadata in
+// opts.RegistryPackagesPath, if any. The schemas are downloaded with ctx.
+func NewSchemaLoader(ctx context.Context, opts SchemaLoaderOptions) (*SchemaLoader, error) {
+	l := &SchemaLoader{
+		ctx:      ctx,
+		opts:     opts,
+		metadata: map[string]PackageMeta{},
+		packages: map[string]*pschema.Package{},
+	}
+
+	if opts.RegistryPackagesPath != "" {
+		packages, err := LoadPackageMetadata(opts.RegistryPackagesPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range packages {
+			l.metadata[p.Name] = p
+		}
+	}
+	return l, nil
+}
+
+// DefaultSchemaCacheDir returns the directory that downloaded schemas are
+// cached in by default.
+func DefaultSchemaCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "registrygen", "schemas")
+	}
+	return filepath.Join(dir, "registrygen", "schemas")
+}
+
+// LoadPackage implements schema.Loader. If version is nil, the version in
+// the package's metadata is used.
+func (l *SchemaLoader) LoadPackage(name string, version *semver.Version) (*pschema.Package, error) {
+	if name == "pulumi" {
+		return pschema.DefaultPulumiPackage, nil
+	}
+
+	if version == nil {
+		meta, ok := l.metadata[name]
+		if !ok || meta.Version == "" {
+			return nil, fmt.Errorf("loading package %s: no version was referenced and the package has no metadata", name)
+		}
+		v, err := semver.ParseTolerant(meta.Version)
+		if err != nil {
+			return nil, fmt.Errorf("parsing the version of package %s: %w", name, err)
+		}
+		version = &v
+	}
+
+	key := name + "@" + version.String()
+	if p, ok := l.packages[key]; ok {
+		return p, nil
+	}
+
+	spec, err := l.loadSpec(name, *version)
+	if err != nil {
+		return nil, fmt.Errorf("loading package %s: %w", key, err)
+	}
+
+	glog.V(2).Infof("Binding the referenced package %s\n", key)
+	p, err := bindPackageSpec(spec, l)
+	if err != nil {
+		return nil, fmt.Errorf("binding package %s: %w", key, err)
+	}
+	l.packages[key] = p
+	return p, nil
+}
+
+// loadSpec reads the schema of a package from the local plugin cache or the
+// schema cache, or else downloads it from the package's repo and caches it.
+func (l *SchemaLoader) loadSpec(name string, version semver.Version) (*pschema.PackageSpec, error) {
+	if p, err := pluginCacheSchemaPath(name, version); err == nil {
+		if spec, err := ReadPackageSpec(p); err == nil {
+			glog.V(2).Infof("Using the schema of %s@%s from the plugin cache\n", name, version)
+			spec.Version = version.String()
+			return spec, nil
+		}
+	}
+
+	cacheFile := ""
+	if l.opts.CacheDir != "" {
+		cacheFile = filepath.Join(l.opts.CacheDir, name, version.String()+".json")
+		if spec, err := ReadPackageSpec(cacheFile); err == nil {
+			glog.V(2).Infof("Using the cached schema of %s@%s\n", name, version)
+			return spec, nil
+		}
+	}
+
+	repoURL := fmt.Sprintf("https://github.com/pulumi/pulumi-%s", name)
+	schemaFile := fmt.Sprintf(defaultSchemaFilePathFormat, name)
+	if meta, ok := l.metadata[name]; ok {
+		if meta.RepoURL != "" {
+			repoURL = meta.RepoURL
+		}
+		if meta.SchemaFilePath != "" {
+			schemaFile = meta.SchemaFilePath
+		}
+	}
+
+	spec, err := FetchPackageSpec(l.ctx, repoURL, "v"+version.String(), schemaFile)
+	if err != nil {
+		return nil, err
+	}
+	spec.Version = version.String()
+
+	if cacheFile != "" {
+		b, err := json.Marshal(spec)
+		if err != nil {
+			return nil, fmt.Errorf("marshalling the schema: %w", err)
+		}
+		if err := EmitFile(filepath.Dir(cacheFile), filepath.Base(cacheFile), b); err != nil {
+			return nil, fmt.Errorf("caching the schema: %w", err)
+		}
+	}
+	return spec, nil
+}
+
+// pluginCacheSchemaPath returns the path that the Pulumi CLI caches the
+// schema of an installed resource plugin at.
+func pluginCacheSchemaPath(name string, version semver.Version) (string, error) {
+	dir, err := workspace.PluginSpec{Kind: workspace.ResourcePlugin, Name: name, Version: &version}.DirPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fmt.Sprintf("schema-%s-%s-.json", name, version)), nil
+}
+
+// bindPackageSpec binds a schema, loading the packages it references with
+// the loader. Without a loader, the references to other packages can't be
+// resolved.
+func bindPackageSpec(spec *pschema.PackageSpec, loader pschema.Loader) (*pschema.Package, error) {
+	if loader == nil {
+		return pschema.ImportSpec(*spec, nil)
+	}
+
+	pulPkg, diags, err := pschema.BindSpec(*spec, loader)
+	if err != nil {
+		return nil, err
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return pulPkg, nil
+}
+
+// linkExternalTypes returns a transform that points the links to the types
+// of other packages, which the docs generator links to as if they were on the
+// same page, to the pages of those types in the registry. Returns nil if the
+// package doesn't use the types of other packages.
+func linkExternalTypes(pulPkg *pschema.Package) func([]byte) []byte {
+	// localAnchors are the anchors of the package's own types, which can't
+	// be told apart from the anchors of other packages' types of the same name.
+	localAnchors := map[string]bool{}
+	for _, t := range pulPkg.Types {
+		switch t := t.(type) {
+		case *pschema.ObjectType:
+			localAnchors[strings.ToLower(tokenName(t.Token))] = true
+		case *pschema.EnumType:
+			localAnchors[strings.ToLower(tokenName(t.Token))] = true
+		}
+	}
+
+	external := map[string]pschema.PackageReference{}
+	visitPackageTypes(pulPkg, func(_ string, t pschema.Type) {
+		var token string
+		var ref pschema.PackageReference
+		switch t := t.(type) {
+		case *pschema.ObjectType:
+			token, ref = t.Token, t.PackageReference
+		case *pschema.EnumType:
+			token, ref = t.Token, t.PackageReference
+		default:
+			return
+		}
+		if ref != nil && ref.Name() != pulPkg.Name && !localAnchors[strings.ToLower(tokenName(token))] {
+			external[token] = ref
+		}
+	})
+	if len(external) == 0 {
+		return nil
+	}
+
+	// typePages are the pages of the types in each of the other packages.
+	typePages := map[string]map[string]string{}
+	links := map[string]string{}
+	for token, ref := range external {
+		pages, ok := typePages[ref.Name()]
+		if !ok {
+			def, err := ref.Definition()
+			if err != nil {
+				glog.V(2).Infof("Unable to load package %s to link to its types: %v", ref.Name(), err)
+				continue
+			}
+			pages = map[string]string{}
+			visitPackageTypes(def, func(page string, t pschema.Type) {
+				switch t := t.(type) {
+				case *pschema.ObjectType:
+					if _, ok := pages[t.Token]; !ok {
+						pages[t.Token] = page
+					}
+				case *pschema.EnumType:
+					if _, ok := pages[t.Token]; !ok {
+						pages[t.Token] = page
+					}
+				}
+			})
+			typePages[ref.Name()] = pages
+		}
+
+		page, ok := pages[token]
+		if !ok {
+			continue
+		}
+		anchor := strings.ToLower(tokenName(token))
+		links[anchor] = apiDocsURLPath(ref.Name(), "") + page + "/#" + anchor
+	}
+
+	anchors := make([]string, 0, len(links))
+	for anchor := range links {
+		anchors = append(anchors, anchor)
+	}
+	sort.Strings(anchors)
+
+	var replacements []string
+	for _, anchor := range anchors {
+		replacements = append(replacements, fmt.Sprintf(`href="#%s"`, anchor), fmt.Sprintf(`href="%s"`, links[anchor]))
+	}
+	replacer := strings.NewReplacer(replacements...)
+	return func(contents []byte) []byte {
+		return []byte(replacer.Replace(string(contents)))
+	}
+}
+
+// visitPackageTypes calls visit with the types used by each of the resources
+// and functions of a package, along with the path of the page of the resource
+// or function relative to the API docs of the package.
+func visitPackageTypes(pulPkg *pschema.Package, visit func(page string, t pschema.Type)) {
+	resources := pulPkg.Resources
+	if pulPkg.Provider != nil {
+		resources = append([]*pschema.Resource{pulPkg.Provider}, resources...)
+	}
+	for _, r := range resources {
+		name := strings.ToLower(tokenName(r.Token))
+		if r.IsProvider {
+			name = "provider"
+		}
+		if name == "index" {
+			name = "--index"
+		}
+		page := path.Join(pulPkg.TokenToModule(r.Token), name)
+
+		properties := append(append([]*pschema.Property{}, r.InputProperties...), r.Properties...)
+		codegen.VisitTypeClosure(properties, func(t pschema.Type) {
+			visit(page, t)
+		})
+	}
+
+	for _, f := range pulPkg.Functions {
+		if f.IsMethod {
+			continue
+		}
+		page := path.Join(pulPkg.TokenToModule(f.Token), strings.ToLower(tokenName(f.Token)))
+
+		var properties []*pschema.Property
+		if f.Inputs != nil {
+			properties = append(properties, f.Inputs.Properties...)
+		}
+		if f.Outputs != nil {
+			properties = append(properties, f.Outputs.Properties...)
+		}
+		codegen.VisitTypeClosure(properties, func(t pschema.Type) {
+			visit(page, t)
+		})
+	}
+}
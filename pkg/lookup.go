@@ -10,6 +10,15 @@ var CategoryNameMap = map[string]PackageCategory{
 	"vcs":            PackageCategoryVCS,
 }
 
+var PackageStatusNameMap = map[string]PackageStatus{
+	"alpha":          PackageStatusAlpha,
+	"beta":           PackageStatusBeta,
+	"deprecated":     PackageStatusDeprecated,
+	"end_of_life":    PackageStatusEndOfLife,
+	"ga":             PackageStatusGA,
+	"public_preview": PackageStatusPublicPreview,
+}
+
 var CategoryLookup = map[string]PackageCategory{
 	"aiven":                               PackageCategoryInfrastructure,
 	"akamai":                              PackageCategoryNetwork,
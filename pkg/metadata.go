@@ -1,5 +1,13 @@
 package pkg
 
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ghodss/yaml"
+)
+
 // PackageStatus is a type to indicate a package's status.
 type PackageStatus string
 
@@ -13,6 +21,18 @@ const (
 	// PackageStatusPublicPreview indicates that a package is available as
 	// pre-release and can undergo changes before it goes GA.
 	PackageStatusPublicPreview PackageStatus = "public_preview"
+	// PackageStatusAlpha indicates that a package is an early pre-release
+	// and is expected to change significantly.
+	PackageStatusAlpha PackageStatus = "alpha"
+	// PackageStatusBeta indicates that a package is feature-complete but
+	// still a pre-release.
+	PackageStatusBeta PackageStatus = "beta"
+	// PackageStatusDeprecated indicates that a package is still available
+	// but should no longer be used for new projects.
+	PackageStatusDeprecated PackageStatus = "deprecated"
+	// PackageStatusEndOfLife indicates that a package is no longer
+	// maintained.
+	PackageStatusEndOfLife PackageStatus = "end_of_life"
 
 	PackageCategoryCloud          PackageCategory = "Cloud"
 	PackageCategoryDatabase       PackageCategory = "Database"
@@ -42,6 +62,17 @@ type PackageMeta struct {
 	Category      PackageCategory `json:"category"`
 	PackageStatus PackageStatus   `json:"package_status"`
 	Version       string          `json:"version"`
+	// DeprecationMessage is an optional message shown to users when the
+	// package is deprecated or has reached end of life.
+	DeprecationMessage string `json:"deprecation_message,omitempty"`
+	// SupersededBy is the name of the package that replaces this one, if any.
+	SupersededBy string `json:"superseded_by,omitempty"`
+	// Versions is the list of versions whose API docs are published, one
+	// per major version, sorted newest first.
+	Versions []string `json:"versions,omitempty"`
+	// Languages restricts the languages rendered in the package's API docs.
+	// If empty, the languages declared in the package's schema are used.
+	Languages []string `json:"languages,omitempty"`
 
 	// Featured indicates whether or not a package is highlighted as
 	// a featured package.
@@ -51,4 +82,39 @@ type PackageMeta struct {
 	// Component indicates if the package is a component and not
 	// a provider.
 	Component bool `json:"component"`
+
+	// PluginRuntime is the language runtime of plugins that aren't binaries,
+	// e.g. nodejs, python, go or dotnet, as declared in their plugin manifest.
+	PluginRuntime string `json:"plugin_runtime,omitempty"`
+	// PluginDownloadURL is the URL of the server the plugin is downloaded
+	// from, if it isn't downloaded from Pulumi's.
+	PluginDownloadURL string `json:"plugin_download_url,omitempty"`
+}
+
+// LoadPackageMetadata reads the metadata files of all of the packages in
+// the registry packages directory.
+func LoadPackageMetadata(registryPackagesPath string) ([]PackageMeta, error) {
+	metadataFiles, err := os.ReadDir(registryPackagesPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading the registry packages dir: %w", err)
+	}
+
+	packages := make([]PackageMeta, 0, len(metadataFiles))
+	for _, packageMetadata := range metadataFiles {
+		metadataFilePath := filepath.Join(registryPackagesPath, packageMetadata.Name())
+
+		b, err := os.ReadFile(metadataFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("reading the metadata file %s: %w", metadataFilePath, err)
+		}
+
+		var metadata PackageMeta
+		if err := yaml.Unmarshal(b, &metadata); err != nil {
+			return nil, fmt.Errorf("unmarshalling the metadata file %s: %w", metadataFilePath, err)
+		}
+
+		packages = append(packages, metadata)
+	}
+
+	return packages, nil
 }
@@ -0,0 +1,133 @@
+package pkg
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	docsgen "github.com/pulumi/pulumi/pkg/v3/codegen/docs"
+)
+
+// mkDocsConfigFile is written next to the docs directory, where MkDocs looks
+// for it by default.
+const mkDocsConfigFile = "mkdocs.yml"
+
+// mkDocsMarkdownExtensions are the extensions that the converted pages need:
+// content tabs, fenced code blocks in tabs, the IDs of the headings and
+// Markdown in the HTML of the property lists.
+var mkDocsMarkdownExtensions = []interface{}{
+	"attr_list",
+	"md_in_html",
+	"pymdownx.superfences",
+	map[string]interface{}{"pymdownx.tabbed": map[string]interface{}{"alternate_style": true}},
+}
+
+// MkDocsAdapter writes the API docs as Markdown pages for MkDocs, with the
+// language choosers rendered as the content tabs of the Material theme, and
+// the package trees as the nav of an mkdocs.yml.
+type MkDocsAdapter struct {
+	packages []adapterPackage
+}
+
+// NewMkDocsAdapter returns an adapter with no packages.
+func NewMkDocsAdapter() *MkDocsAdapter {
+	return &MkDocsAdapter{}
+}
+
+// ConvertPackage converts each page to <dir>/index.md.
+func (a *MkDocsAdapter) ConvertPackage(meta PackageMeta, outDir string, files map[string][]byte,
+	nav []docsgen.PackageTreeItem) (map[string][]byte, error) {
+	out := map[string][]byte{}
+	for f, contents := range files {
+		page := convertDocsPage(meta.Name, f, contents, mkDocsTabs)
+
+		fm, err := yaml.Marshal(map[string]string{
+			"title":       page.Title,
+			"description": page.Description,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("marshalling the front matter of %s: %w", f, err)
+		}
+
+		contents := fmt.Sprintf("---\n%s---\n\n%s", fm, page.Body)
+		out[path.Join(path.Dir(f), "index.md")] = []byte(contents)
+	}
+
+	a.packages = append(a.packages, adapterPackage{Meta: meta, OutDir: outDir, Nav: nav})
+	return out, nil
+}
+
+// Write writes an mkdocs.yml with the nav of the packages and the Markdown
+// extensions that the pages need to the parent directory of docsDir.
+func (a *MkDocsAdapter) Write(docsDir string) error {
+	docsDir = filepath.Clean(docsDir)
+
+	var nav []interface{}
+	for _, p := range a.packages {
+		dir, err := p.docsDirPath(docsDir)
+		if err != nil {
+			return err
+		}
+		items := append([]interface{}{dir + "index.md"}, mkDocsNavItems(resolveNav(p.Nav, dir))...)
+		nav = append(nav, map[string]interface{}{p.Meta.Title: items})
+	}
+	// The nav of a single package is the nav of the site.
+	if len(a.packages) == 1 {
+		nav = nav[0].(map[string]interface{})[a.packages[0].Meta.Title].([]interface{})
+	}
+
+	siteName := "API Docs"
+	if len(a.packages) == 1 {
+		siteName = a.packages[0].Meta.Title
+	}
+	b, err := yaml.Marshal(map[string]interface{}{
+		"site_name":           siteName,
+		"docs_dir":            filepath.Base(docsDir),
+		"theme":               map[string]interface{}{"name": "material", "features": []string{"navigation.indexes", "content.tabs.link"}},
+		"markdown_extensions": mkDocsMarkdownExtensions,
+		"nav":                 nav,
+	})
+	if err != nil {
+		return fmt.Errorf("marshalling %s: %w", mkDocsConfigFile, err)
+	}
+	contents := "# This file was generated by registrygen.\n" + string(b)
+	if err := EmitFile(filepath.Dir(docsDir), mkDocsConfigFile, []byte(contents)); err != nil {
+		return fmt.Errorf("writing %s: %w", mkDocsConfigFile, err)
+	}
+	return nil
+}
+
+// mkDocsNavItems returns the nav of a package tree. The page of a module is
+// the first item of its section so that it's the section's index page.
+func mkDocsNavItems(nav []adapterNavItem) []interface{} {
+	var items []interface{}
+	for _, item := range nav {
+		page := item.Dir + "index.md"
+		if len(item.Children) == 0 {
+			items = append(items, map[string]interface{}{item.Name: page})
+			continue
+		}
+		children := append([]interface{}{page}, mkDocsNavItems(item.Children)...)
+		items = append(items, map[string]interface{}{item.Name: children})
+	}
+	return items
+}
+
+// mkDocsTabs renders the content tabs of the Material theme, which are
+// linked across the pages by their label.
+func mkDocsTabs(tabs []docsTab) string {
+	var sb strings.Builder
+	for _, tab := range tabs {
+		fmt.Fprintf(&sb, "=== %q\n\n", tab.Label)
+		for _, line := range strings.Split(tab.Content, "\n") {
+			if strings.TrimSpace(line) != "" {
+				sb.WriteString("    " + line)
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
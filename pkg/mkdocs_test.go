@@ -0,0 +1,157 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	docsgen "github.com/pulumi/pulumi/pkg/v3/codegen/docs"
+)
+
+func TestMkDocsAdapterConvertPackage(t *testing.T) {
+	adapter := NewMkDocsAdapter()
+	files, err := adapter.ConvertPackage(PackageMeta{Name: "random", Title: "Random"}, t.TempDir(),
+		map[string][]byte{
+			"randompet/_index.md": []byte(`---
+title: RandomPet
+meta_desc: "Docs for the random.RandomPet resource."
+---
+
+See [RandomId](/registry/packages/random/api-docs/randomid/).
+
+<pulumi-choosable type="language" values="go">
+
+` + "```go\nfunc main() {\n\n}\n```" + `
+
+</pulumi-choosable>
+<pulumi-choosable type="language" values="typescript">ts</pulumi-choosable>
+`),
+		}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `---
+description: Docs for the random.RandomPet resource.
+title: RandomPet
+---
+
+See [RandomId](../randomid/).
+
+
+
+=== "TypeScript"
+
+    ts
+
+=== "Go"
+
+    ` + "```go\n    func main() {\n\n    }\n    ```" + `
+`
+	if actual := string(files["randompet/index.md"]); actual != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestMkDocsAdapterWrite(t *testing.T) {
+	nav := []docsgen.PackageTreeItem{
+		{
+			Name: "random",
+			Link: "random/",
+			Children: []docsgen.PackageTreeItem{
+				{Name: "RandomPet", Link: "randompet/"},
+			},
+		},
+		{Name: "Provider", Link: "provider/"},
+	}
+
+	tests := []struct {
+		name     string
+		packages []string
+		expected string
+	}{
+		{
+			name:     "single package",
+			packages: []string{"random"},
+			expected: `# This file was generated by registrygen.
+docs_dir: docs
+markdown_extensions:
+- attr_list
+- md_in_html
+- pymdownx.superfences
+- pymdownx.tabbed:
+    alternate_style: true
+nav:
+- random/index.md
+- random:
+  - random/random/index.md
+  - RandomPet: random/random/randompet/index.md
+- Provider: random/provider/index.md
+site_name: random
+theme:
+  features:
+  - navigation.indexes
+  - content.tabs.link
+  name: material
+`,
+		},
+		{
+			name:     "multiple packages",
+			packages: []string{"random", "tls"},
+			expected: `# This file was generated by registrygen.
+docs_dir: docs
+markdown_extensions:
+- attr_list
+- md_in_html
+- pymdownx.superfences
+- pymdownx.tabbed:
+    alternate_style: true
+nav:
+- random:
+  - random/index.md
+  - random:
+    - random/random/index.md
+    - RandomPet: random/random/randompet/index.md
+  - Provider: random/provider/index.md
+- tls:
+  - tls/index.md
+  - random:
+    - tls/random/index.md
+    - RandomPet: tls/random/randompet/index.md
+  - Provider: tls/provider/index.md
+site_name: API Docs
+theme:
+  features:
+  - navigation.indexes
+  - content.tabs.link
+  name: material
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			siteDir := t.TempDir()
+			docsDir := filepath.Join(siteDir, "docs")
+
+			adapter := NewMkDocsAdapter()
+			for _, name := range tt.packages {
+				_, err := adapter.ConvertPackage(PackageMeta{Name: name, Title: name},
+					filepath.Join(docsDir, name), map[string][]byte{}, nav)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := adapter.Write(docsDir); err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := os.ReadFile(filepath.Join(siteDir, mkDocsConfigFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := string(b); actual != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, actual)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package pkg
 import (
 	"os"
 	"path"
+	"path/filepath"
 
 	"github.com/pkg/errors"
 
@@ -11,7 +12,8 @@ import (
 )
 
 // EmitFile writes the file with the provided contents in the output
-// directory outDir.
+// directory outDir. The contents are written to a temporary file that is
+// renamed into place, so the file is never left half-written.
 func EmitFile(outDir, relPath string, contents []byte) error {
 	if contents == nil {
 		return nil
@@ -23,12 +25,92 @@ func EmitFile(outDir, relPath string, contents []byte) error {
 		return errors.Wrap(err, "creating directory")
 	}
 
-	f, err := os.Create(p)
+	f, err := os.CreateTemp(path.Dir(p), "."+path.Base(p)+".tmp-")
 	if err != nil {
 		return errors.Wrap(err, "creating file")
 	}
+	defer os.Remove(f.Name())
 	defer contract.IgnoreClose(f)
 
-	_, err = f.Write(contents)
-	return err
+	if _, err := f.Write(contents); err != nil {
+		return errors.Wrap(err, "writing file")
+	}
+	if err := f.Chmod(0644); err != nil {
+		return errors.Wrap(err, "setting file mode")
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "writing file")
+	}
+	return errors.Wrap(os.Rename(f.Name(), p), "renaming file")
+}
+
+// replaceDir replaces the contents of dir with the files, keyed by their path
+// relative to dir. The files are written to a staging directory next to dir
+// that is swapped into place once all of them are written, so dir is left as
+// is if writing them fails.
+func replaceDir(dir string, files map[string][]byte) error {
+	stageDir, err := stagingDir(dir)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stageDir)
+
+	for f, contents := range files {
+		if err := EmitFile(stageDir, f, contents); err != nil {
+			return errors.Wrapf(err, "emitting file %v", f)
+		}
+	}
+
+	return swapDir(dir, stageDir)
+}
+
+// stagingDir creates an empty directory next to dir to write its new
+// contents to before they're swapped into place with swapDir.
+func stagingDir(dir string) (string, error) {
+	dir = filepath.Clean(dir)
+	parent, base := filepath.Dir(dir), filepath.Base(dir)
+	if err := tools.EnsureDir(parent); err != nil {
+		return "", errors.Wrap(err, "creating directory")
+	}
+
+	stageDir, err := os.MkdirTemp(parent, "."+base+".staging-")
+	if err != nil {
+		return "", errors.Wrap(err, "creating staging directory")
+	}
+	// MkdirTemp creates the directory with 0700.
+	if err := os.Chmod(stageDir, 0755); err != nil {
+		os.RemoveAll(stageDir)
+		return "", errors.Wrap(err, "setting directory mode")
+	}
+	return stageDir, nil
+}
+
+// swapDir replaces dir with stageDir, which must be next to it. dir is
+// restored if stageDir can't be moved into place.
+func swapDir(dir, stageDir string) error {
+	dir = filepath.Clean(dir)
+	parent, base := filepath.Dir(dir), filepath.Base(dir)
+
+	// The existing directory is moved out of the way rather than removed
+	// until the staging directory is in place, so that it can be restored.
+	backupDir := ""
+	if _, err := os.Stat(dir); err == nil {
+		backupDir = filepath.Join(parent, "."+base+".old-"+filepath.Base(stageDir))
+		if err := os.Rename(dir, backupDir); err != nil {
+			return errors.Wrap(err, "moving the existing directory")
+		}
+	}
+	if err := os.Rename(stageDir, dir); err != nil {
+		if backupDir != "" {
+			if restoreErr := os.Rename(backupDir, dir); restoreErr != nil {
+				return errors.Wrapf(err, "replacing the directory, and restoring it from %s failed: %v", backupDir,
+					restoreErr)
+			}
+		}
+		return errors.Wrap(err, "replacing the directory")
+	}
+	if backupDir != "" {
+		return errors.Wrap(os.RemoveAll(backupDir), "removing the previous directory")
+	}
+	return nil
 }
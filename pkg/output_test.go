@@ -0,0 +1,82 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// assertNoStagingLeftovers fails if any of the staging or backup directories
+// of replaceDir, or the temporary files of EmitFile, are left in dir.
+func assertNoStagingLeftovers(t *testing.T, dir string) {
+	t.Helper()
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name := info.Name()
+		if strings.Contains(name, ".staging-") || strings.Contains(name, ".old-") || strings.Contains(name, ".tmp-") {
+			t.Errorf("%s was left behind", p)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplaceDirReplacesContents(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "api-docs")
+	if err := EmitFile(dir, "stale/_index.md", []byte("stale")); err != nil {
+		t.Fatal(err)
+	}
+
+	err := replaceDir(dir, map[string][]byte{
+		"_index.md":        []byte("index"),
+		"module/_index.md": []byte("module"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "module", "_index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "module" {
+		t.Errorf("expected module/_index.md to be written, got %q", b)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "stale")); !os.IsNotExist(err) {
+		t.Errorf("expected the stale files to be removed, got %v", err)
+	}
+	assertNoStagingLeftovers(t, parent)
+}
+
+func TestReplaceDirKeepsExistingDirOnFailure(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "api-docs")
+	if err := EmitFile(dir, "_index.md", []byte("existing")); err != nil {
+		t.Fatal(err)
+	}
+
+	// module can't be both a file and the directory of module/_index.md.
+	err := replaceDir(dir, map[string][]byte{
+		"_index.md":        []byte("new"),
+		"module":           []byte("module"),
+		"module/_index.md": []byte("module"),
+	})
+	if err == nil {
+		t.Fatal("expected replacing the directory to fail")
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "_index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "existing" {
+		t.Errorf("expected the existing directory to be kept, got _index.md %q", b)
+	}
+	assertNoStagingLeftovers(t, parent)
+}
@@ -0,0 +1,126 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/ghodss/yaml"
+)
+
+// PluginManifestFiles are the names of the plugin manifests that are looked
+// for at the root of a repo, in order.
+var PluginManifestFiles = []string{"PulumiPlugin.yaml", "PulumiPlugin.yml", "pulumi-plugin.json"}
+
+// PluginManifest is the identity of a package described by its
+// PulumiPlugin.yaml or pulumi-plugin.json.
+type PluginManifest struct {
+	// Path is the path of the manifest in the repo.
+	Path    string
+	Name    string
+	Version string
+	// Runtime is the language runtime of the plugin, e.g. nodejs, for
+	// plugins that aren't binaries.
+	Runtime string
+	// Server is the URL that the plugin is downloaded from.
+	Server string
+}
+
+// pluginManifestFile holds the keys of both PulumiPlugin.yaml and
+// pulumi-plugin.json.
+type pluginManifestFile struct {
+	// Runtime is either the name of the runtime or an object with the name
+	// of the runtime and its options.
+	Runtime interface{} `json:"runtime"`
+	Name    string      `json:"name"`
+	Version string      `json:"version"`
+	Server  string      `json:"server"`
+}
+
+// ParsePluginManifest parses the contents of a PulumiPlugin.yaml or
+// pulumi-plugin.json.
+func ParsePluginManifest(manifestPath string, contents []byte) (*PluginManifest, error) {
+	// JSON is valid YAML so both manifests can be parsed the same way.
+	var f pluginManifestFile
+	if err := yaml.Unmarshal(contents, &f); err != nil {
+		return nil, fmt.Errorf("parsing plugin manifest %s: %w", manifestPath, err)
+	}
+
+	m := &PluginManifest{Path: manifestPath, Name: f.Name, Version: f.Version, Server: f.Server}
+	switch runtime := f.Runtime.(type) {
+	case string:
+		m.Runtime = runtime
+	case map[string]interface{}:
+		if name, ok := runtime["name"].(string); ok {
+			m.Runtime = name
+		}
+	}
+	return m, nil
+}
+
+// FetchPluginManifest downloads and parses a plugin manifest from a repo at
+// a given ref. Returns nil if the manifest doesn't exist.
+func FetchPluginManifest(ctx context.Context, repoSlug, ref, manifestPath string) (*PluginManifest, error) {
+	contents, err := readGitHubFile(ctx, repoSlug, ref, manifestPath)
+	if err != nil || contents == nil {
+		return nil, err
+	}
+	return ParsePluginManifest(manifestPath, contents)
+}
+
+// FindPluginManifest returns the first of the PluginManifestFiles at the
+// root of a repo at a given ref. Returns nil if there's none.
+func FindPluginManifest(ctx context.Context, repoSlug, ref string) (*PluginManifest, error) {
+	for _, f := range PluginManifestFiles {
+		m, err := FetchPluginManifest(ctx, repoSlug, ref, f)
+		if err != nil || m != nil {
+			return m, err
+		}
+	}
+	return nil, nil
+}
+
+// ResolveSchemaFile returns the path of the schema of the package described
+// by the manifest. The schema is looked for next to the manifest and, if the
+// manifest has a name, at the path used by providers.
+func (m *PluginManifest) ResolveSchemaFile(ctx context.Context, repoSlug, ref string) (string, error) {
+	dir := path.Dir(m.Path)
+	candidates := []string{
+		path.Join(dir, "schema.json"),
+		path.Join(dir, "schema.yaml"),
+		path.Join(dir, "schema.yml"),
+	}
+	if m.Name != "" {
+		candidates = append(candidates, fmt.Sprintf("provider/cmd/pulumi-resource-%s/schema.json", m.Name))
+	}
+
+	for _, c := range candidates {
+		exists, err := existsInGitHubRepo(ctx, repoSlug, ref, c)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("no schema found for the plugin manifest %s, looked for %v", m.Path, candidates)
+}
+
+func existsInGitHubRepo(ctx context.Context, repoSlug, ref, filePath string) (bool, error) {
+	url := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s", repoSlug, ref, filePath)
+	resp, err := httpRequest(ctx, http.MethodHead, url)
+	if err != nil {
+		return false, fmt.Errorf("checking %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return true, nil
+	default:
+		return false, fmt.Errorf("checking %s: %s", url, resp.Status)
+	}
+}
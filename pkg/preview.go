@@ -0,0 +1,274 @@
+package pkg
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	docsgen "github.com/pulumi/pulumi/pkg/v3/codegen/docs"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+const (
+	// previewAPIDocsPath is the URL path that the API docs are served from by
+	// the preview server, relative to the package docs like in the registry.
+	previewAPIDocsPath = "/api-docs/"
+	// previewGenerationPath is polled by the pages to reload when the docs
+	// are regenerated.
+	previewGenerationPath = "/__generation"
+
+	defaultPreviewPollInterval = time.Second
+)
+
+// PreviewOptions controls what the preview server renders.
+type PreviewOptions struct {
+	SchemaFile string
+	// OverlaySchemaFile is an optional schema whose resources, types and
+	// language info are merged into the schema.
+	OverlaySchemaFile string
+	// DocsDir is an optional directory of package docs, e.g. the docs
+	// directory of the package's repo, served at the root.
+	DocsDir string
+	// PollInterval is how often the files are checked for changes.
+	PollInterval time.Duration
+	// SchemaLoader loads the packages referenced by the schema.
+	SchemaLoader pschema.Loader
+}
+
+// PreviewServer serves the docs of a local schema rendered to HTML and
+// regenerates them whenever the schema or the package docs change.
+type PreviewServer struct {
+	opts PreviewOptions
+
+	mu         sync.RWMutex
+	pkgName    string
+	pages      map[string]docsPage
+	nav        []docsgen.PackageTreeItem
+	generation int
+	err        error
+}
+
+// NewPreviewServer returns a preview server. The docs are generated in
+// memory, so nothing is written to disk.
+func NewPreviewServer(opts PreviewOptions) *PreviewServer {
+	if opts.PollInterval == 0 {
+		opts.PollInterval = defaultPreviewPollInterval
+	}
+	return &PreviewServer{opts: opts}
+}
+
+// Generate regenerates the docs. If the generation fails, the error is
+// served instead of the docs until the next generation.
+func (s *PreviewServer) Generate() error {
+	pkgName, pages, nav, err := s.generate()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.generation++
+	s.err = err
+	if err == nil {
+		s.pkgName, s.pages, s.nav = pkgName, pages, nav
+	}
+	return err
+}
+
+func (s *PreviewServer) generate() (string, map[string]docsPage, []docsgen.PackageTreeItem, error) {
+	spec, err := ReadPackageSpec(s.opts.SchemaFile)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if s.opts.OverlaySchemaFile != "" {
+		overlay, err := ReadPackageSpec(s.opts.OverlaySchemaFile)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		if err := mergeOverlaySchemaSpec(spec, overlay); err != nil {
+			return "", nil, nil, fmt.Errorf("merging the overlay schema: %w", err)
+		}
+	}
+
+	mainSpec = spec
+	pulPkg, err := getPulumiPackageFromSchema(s.opts.SchemaLoader)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("generating package from schema file: %w", err)
+	}
+
+	var transforms []func([]byte) []byte
+	if link := linkExternalTypes(pulPkg); link != nil {
+		transforms = append(transforms, link)
+	}
+	files, err := generateDocsFromSchema(pulPkg, transforms...)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("generating docs from schema: %w", err)
+	}
+
+	nav, err := docsgen.GeneratePackageTree()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("generating the package tree: %w", err)
+	}
+
+	pages := map[string]docsPage{}
+	for f, contents := range files {
+		page, err := renderDocsPage(pulPkg.Name, f, contents, "/", previewAPIDocsPath)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		pages[docsPageURLPath(previewAPIDocsPath, f)] = page
+	}
+
+	if s.opts.DocsDir != "" {
+		docs, err := ReadFileMap(s.opts.DocsDir)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		for f, contents := range docs {
+			if path.Ext(f) != ".md" {
+				continue
+			}
+			page, err := renderDocsPage(pulPkg.Name, f, contents, "/", previewAPIDocsPath)
+			if err != nil {
+				return "", nil, nil, err
+			}
+			pages[docsFileURLPath(f)] = page
+		}
+	}
+
+	return pulPkg.Name, pages, nav, nil
+}
+
+// docsFileURLPath returns the URL path of a page of the package docs, e.g.
+// installation-configuration.md is served at /installation-configuration/.
+func docsFileURLPath(f string) string {
+	if path.Base(f) == "_index.md" {
+		return docsPageURLPath("/", f)
+	}
+	return "/" + strings.TrimSuffix(f, ".md") + "/"
+}
+
+// Watch regenerates the docs whenever the schema, the overlay schema or the
+// package docs change, until stop is closed.
+func (s *PreviewServer) Watch(stop <-chan struct{}, log io.Writer) {
+	last := s.fingerprint()
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		current := s.fingerprint()
+		if current == last {
+			continue
+		}
+		last = current
+
+		start := time.Now()
+		if err := s.Generate(); err != nil {
+			fmt.Fprintf(log, "Error regenerating the docs: %v\n", err)
+			continue
+		}
+		fmt.Fprintf(log, "Regenerated the docs in %s\n", time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// fingerprint returns the size and modification time of each of the watched
+// files.
+func (s *PreviewServer) fingerprint() string {
+	var files []string
+	for _, f := range []string{s.opts.SchemaFile, s.opts.OverlaySchemaFile} {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	if s.opts.DocsDir != "" {
+		err := filepath.Walk(s.opts.DocsDir, func(p string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			glog.V(2).Infof("Unable to list the files in %s: %v", s.opts.DocsDir, err)
+		}
+	}
+	sort.Strings(files)
+
+	var sb strings.Builder
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			fmt.Fprintf(&sb, "%s:missing\n", f)
+			continue
+		}
+		fmt.Fprintf(&sb, "%s:%d:%d\n", f, info.Size(), info.ModTime().UnixNano())
+	}
+	return sb.String()
+}
+
+// ServeHTTP serves the rendered pages.
+func (s *PreviewServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if r.URL.Path == previewGenerationPath {
+		w.Header().Set("Cache-Control", "no-store")
+		fmt.Fprint(w, strconv.Itoa(s.generation))
+		return
+	}
+
+	p := r.URL.Path
+	if p == previewAPIDocsPath+navScriptFile {
+		script, err := navScript(s.nav)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/javascript")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(script)
+		return
+	}
+	if !strings.HasSuffix(p, "/") {
+		http.Redirect(w, r, p+"/", http.StatusMovedPermanently)
+		return
+	}
+
+	page, ok := s.pages[p]
+	switch {
+	case ok:
+	case p == "/":
+		// The package docs don't have a landing page.
+		http.Redirect(w, r, previewAPIDocsPath, http.StatusFound)
+		return
+	default:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if s.err == nil {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		page = docsPage{Title: "Not found", Body: template.HTML(fmt.Sprintf(
+			"<p>There is no page at %s.</p>", template.HTMLEscapeString(p))),
+			HomeURL: "/", APIDocsURL: previewAPIDocsPath}
+	}
+	page.Package = s.pkgName
+	page.Generation = s.generation
+	if s.err != nil {
+		page.Error = s.err.Error()
+	}
+
+	if err := docsPageTemplate.Execute(w, page); err != nil {
+		glog.Errorf("Rendering %s: %v", p, err)
+	}
+}
@@ -0,0 +1,53 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Progress tracks the packages that a command has completed, so that it can
+// report what was done if it's interrupted or times out.
+type Progress struct {
+	// what describes the work done for each package, e.g. "generating the
+	// docs of".
+	what  string
+	items []string
+	done  map[string]bool
+}
+
+// NewProgress returns the progress of the work described by what over the
+// packages in items, none of which are completed.
+func NewProgress(what string, items []string) *Progress {
+	return &Progress{what: what, items: items, done: map[string]bool{}}
+}
+
+// Done marks an item as completed.
+func (p *Progress) Done(item string) {
+	p.done[item] = true
+}
+
+// ReportCanceled writes the completed and the remaining items to w if ctx
+// was canceled or timed out. Nothing is written otherwise.
+func (p *Progress) ReportCanceled(ctx context.Context, w io.Writer) {
+	if ctx.Err() == nil {
+		return
+	}
+
+	var done, remaining []string
+	for _, item := range p.items {
+		if p.done[item] {
+			done = append(done, item)
+		} else {
+			remaining = append(remaining, item)
+		}
+	}
+	fmt.Fprintf(w, "Stopped (%v) after %s %d of %d package(s)\n", ctx.Err(), p.what, len(done), len(p.items))
+	if len(done) > 0 {
+		fmt.Fprintf(w, "  Completed: %s\n", strings.Join(done, ", "))
+	}
+	if len(remaining) > 0 {
+		fmt.Fprintf(w, "  Not completed: %s\n", strings.Join(remaining, ", "))
+	}
+}
@@ -0,0 +1,269 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// publishDocsDir is the directory of the docs in the uploaded tarball.
+	publishDocsDir = "docs"
+	// publishNavFile is the name of the nav tree in the uploaded tarball.
+	publishNavFile = "nav.json"
+
+	defaultPublishRetryDelay = time.Second
+)
+
+// PublishOptions configures the registry API that a package is published to.
+type PublishOptions struct {
+	// Endpoint is the base URL of the registry API.
+	Endpoint string
+	// Token is sent as a bearer token if set.
+	Token string
+	// DryRun logs the requests that would be made instead of making them.
+	DryRun bool
+	// Retries is the number of times a request is retried after a network
+	// error, a 429 or a 5xx response.
+	Retries int
+	// RetryDelay is the delay before the first retry, doubled after each
+	// retry unless the response has a Retry-After header.
+	RetryDelay time.Duration
+	// Log receives a line for each request. Defaults to stdout.
+	Log io.Writer
+	// Client is the HTTP client used to make the requests. Defaults to a
+	// client with RequestTimeout.
+	Client *http.Client
+}
+
+// PublishBundle is the content of a package version published to a
+// registry API.
+type PublishBundle struct {
+	Metadata PackageMeta
+	// Files are the generated docs keyed by their path relative to the docs
+	// directory of the package.
+	Files map[string][]byte
+	// NavTree is the package tree JSON of the docs.
+	NavTree []byte
+}
+
+// registryPublisher makes the requests of the registry API's publishing
+// contract.
+type registryPublisher struct {
+	opts PublishOptions
+}
+
+// Publish publishes a package version to a registry API in three steps:
+//
+//  1. POST <endpoint>/packages/<name>/versions with the metadata to create the version.
+//  2. PUT <endpoint>/packages/<name>/versions/<version>/content with a tarball of the docs and nav tree.
+//  3. POST <endpoint>/packages/<name>/versions/<version>/finalize to make the version visible.
+//
+// Each step is idempotent so that a failed publish can be run again: a 409
+// response to creating or finalizing a version means that it was done
+// already, and the content is replaced by the upload.
+func Publish(ctx context.Context, opts PublishOptions, bundle PublishBundle) error {
+	if opts.Endpoint == "" {
+		return fmt.Errorf("the registry endpoint is required")
+	}
+	if bundle.Metadata.Name == "" || bundle.Metadata.Version == "" {
+		return fmt.Errorf("the metadata of the package must have a name and a version")
+	}
+	if opts.Log == nil {
+		opts.Log = os.Stdout
+	}
+	if opts.Client == nil {
+		opts.Client = httpClient()
+	}
+	if opts.RetryDelay == 0 {
+		opts.RetryDelay = defaultPublishRetryDelay
+	}
+	p := &registryPublisher{opts: opts}
+
+	name, version := bundle.Metadata.Name, bundle.Metadata.Version
+	versionURL := fmt.Sprintf("%s/packages/%s/versions", strings.TrimSuffix(opts.Endpoint, "/"), url.PathEscape(name))
+	key := fmt.Sprintf("%s@%s", name, version)
+
+	body, err := json.Marshal(map[string]interface{}{
+		"version":  version,
+		"metadata": bundle.Metadata,
+	})
+	if err != nil {
+		return fmt.Errorf("marshalling the metadata: %w", err)
+	}
+	if err := p.do(ctx, http.MethodPost, versionURL, "application/json", body, key+":create"); err != nil {
+		return fmt.Errorf("creating version %s: %w", key, err)
+	}
+
+	tarball, err := docsTarball(bundle.Files, bundle.NavTree)
+	if err != nil {
+		return fmt.Errorf("creating the docs tarball: %w", err)
+	}
+	versionURL += "/" + url.PathEscape(version)
+	if err := p.do(ctx, http.MethodPut, versionURL+"/content", "application/gzip", tarball, key+":content"); err != nil {
+		return fmt.Errorf("uploading the docs of %s: %w", key, err)
+	}
+
+	if err := p.do(ctx, http.MethodPost, versionURL+"/finalize", "", nil, key+":finalize"); err != nil {
+		return fmt.Errorf("finalizing version %s: %w", key, err)
+	}
+	return nil
+}
+
+// do makes a request, retrying it if it fails with a network error, a 429
+// or a 5xx response, until ctx is done. A 409 response is treated as a
+// success.
+func (p *registryPublisher) do(ctx context.Context, method, u, contentType string, body []byte, idempotencyKey string) error {
+	sum := sha256.Sum256(body)
+	checksum := hex.EncodeToString(sum[:])
+
+	if p.opts.DryRun {
+		fmt.Fprintf(p.opts.Log, "[dry-run] %s %s (%d bytes, sha256 %s)\n", method, u, len(body), checksum)
+		return nil
+	}
+
+	delay := p.opts.RetryDelay
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("creating request: %w", err)
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if p.opts.Token != "" {
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.opts.Token))
+		}
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+		req.Header.Set("X-Content-SHA256", checksum)
+
+		retry, err := p.send(req)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= p.opts.Retries || ctx.Err() != nil {
+			return err
+		}
+
+		wait := delay
+		if retry, ok := err.(*retryAfterError); ok && retry.after > 0 {
+			wait = retry.after
+		}
+		fmt.Fprintf(p.opts.Log, "%s %s failed, retrying in %s: %v\n", method, u, wait, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// retryAfterError is the error of a response that can be retried, along
+// with the delay requested by its Retry-After header, if any.
+type retryAfterError struct {
+	err   error
+	after time.Duration
+}
+
+func (e *retryAfterError) Error() string {
+	return e.err.Error()
+}
+
+// send makes a request and returns whether it can be retried if it fails.
+func (p *registryPublisher) send(req *http.Request) (bool, error) {
+	resp, err := p.opts.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		fmt.Fprintf(p.opts.Log, "%s %s: %s\n", req.Method, req.URL, resp.Status)
+		return false, nil
+	case resp.StatusCode == http.StatusConflict:
+		fmt.Fprintf(p.opts.Log, "%s %s: %s, already done\n", req.Method, req.URL, resp.Status)
+		return false, nil
+	}
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	err = fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return false, err
+	}
+
+	retryErr := &retryAfterError{err: err}
+	if s, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil {
+		retryErr.after = time.Duration(s) * time.Second
+	}
+	return true, retryErr
+}
+
+// docsTarball creates a gzipped tarball of the docs files under docs/ and
+// the nav tree as nav.json. The entries are sorted and have no timestamps so
+// that the same content always results in the same tarball.
+func docsTarball(files map[string][]byte, navTree []byte) ([]byte, error) {
+	entries := map[string][]byte{}
+	for f, contents := range files {
+		entries[publishDocsDir+"/"+filepath.ToSlash(f)] = contents
+	}
+	if navTree != nil {
+		entries[publishNavFile] = navTree
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	w := newTarGzWriter(&buf)
+	for _, name := range names {
+		if err := w.add(name, int64(len(entries[name])), bytes.NewReader(entries[name])); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ReadFileMap reads the files in dir, recursively, keyed by their path
+// relative to dir.
+func ReadFileMap(dir string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		contents, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", p, err)
+		}
+		files[filepath.ToSlash(rel)] = contents
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading the files in %s: %w", dir, err)
+	}
+	return files, nil
+}
@@ -0,0 +1,243 @@
+package pkg
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testRegistry is a registry API that responds to the requests with the
+// given statuses, in order, and then with 200s.
+type testRegistry struct {
+	mu       sync.Mutex
+	statuses []int
+	headers  []http.Header
+	requests []string
+	bodies   [][]byte
+}
+
+func (r *testRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req.Method+" "+req.URL.Path)
+	r.headers = append(r.headers, req.Header.Clone())
+	r.bodies = append(r.bodies, body)
+
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	if status == http.StatusTooManyRequests {
+		w.Header().Set("Retry-After", "1")
+	}
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, http.StatusText(status))
+}
+
+func (r *testRegistry) start(t *testing.T) string {
+	t.Helper()
+	s := httptest.NewServer(r)
+	t.Cleanup(s.Close)
+	return s.URL
+}
+
+func testPublishBundle() PublishBundle {
+	return PublishBundle{
+		Metadata: PackageMeta{Name: "random", Title: "Random", Version: "v4.3.1"},
+		Files:    map[string][]byte{"_index.md": []byte("# Random\n")},
+		NavTree:  []byte("[]"),
+	}
+}
+
+func TestPublish(t *testing.T) {
+	registry := &testRegistry{}
+	opts := PublishOptions{Endpoint: registry.start(t) + "/", Token: "token", Log: io.Discard}
+	if err := Publish(context.Background(), opts, testPublishBundle()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"POST /packages/random/versions",
+		"PUT /packages/random/versions/v4.3.1/content",
+		"POST /packages/random/versions/v4.3.1/finalize",
+	}
+	if !reflect.DeepEqual(registry.requests, expected) {
+		t.Fatalf("expected the requests %v, got %v", expected, registry.requests)
+	}
+	for i, h := range registry.headers {
+		if auth := h.Get("Authorization"); auth != "Bearer token" {
+			t.Errorf("%s: expected the bearer token, got %q", registry.requests[i], auth)
+		}
+		sum := sha256.Sum256(registry.bodies[i])
+		if checksum := h.Get("X-Content-SHA256"); checksum != hex.EncodeToString(sum[:]) {
+			t.Errorf("%s: X-Content-SHA256 %s doesn't match the body", registry.requests[i], checksum)
+		}
+	}
+	for i, key := range []string{"random@v4.3.1:create", "random@v4.3.1:content", "random@v4.3.1:finalize"} {
+		if actual := registry.headers[i].Get("Idempotency-Key"); actual != key {
+			t.Errorf("%s: expected the idempotency key %s, got %s", registry.requests[i], key, actual)
+		}
+	}
+
+	if contentType := registry.headers[1].Get("Content-Type"); contentType != "application/gzip" {
+		t.Errorf("expected the content to be uploaded as application/gzip, got %s", contentType)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(registry.bodies[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+	}
+	if expected := []string{"docs/_index.md", "nav.json"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected the tarball to contain %v, got %v", expected, names)
+	}
+}
+
+func TestPublishTreatsConflictsAsDone(t *testing.T) {
+	registry := &testRegistry{statuses: []int{http.StatusConflict, http.StatusOK, http.StatusConflict}}
+	opts := PublishOptions{Endpoint: registry.start(t), Log: io.Discard}
+	if err := Publish(context.Background(), opts, testPublishBundle()); err != nil {
+		t.Fatal(err)
+	}
+	if len(registry.requests) != 3 {
+		t.Errorf("expected 3 requests, got %v", registry.requests)
+	}
+}
+
+func TestPublishRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		retries  int
+		requests int
+		problem  string
+	}{
+		{
+			name:     "server errors are retried",
+			statuses: []int{http.StatusServiceUnavailable, http.StatusBadGateway},
+			retries:  2,
+			requests: 5,
+		},
+		{
+			name:     "too many requests are retried after Retry-After",
+			statuses: []int{http.StatusTooManyRequests},
+			retries:  1,
+			requests: 4,
+		},
+		{
+			name:     "retries are limited",
+			statuses: []int{http.StatusInternalServerError, http.StatusInternalServerError},
+			retries:  1,
+			requests: 2,
+			problem:  "creating version random@v4.3.1: 500 Internal Server Error",
+		},
+		{
+			name:     "client errors aren't retried",
+			statuses: []int{http.StatusOK, http.StatusBadRequest},
+			retries:  3,
+			requests: 2,
+			problem:  "uploading the docs of random@v4.3.1: 400 Bad Request",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := &testRegistry{statuses: tt.statuses}
+			opts := PublishOptions{
+				Endpoint: registry.start(t),
+				Retries:  tt.retries,
+				Log:      io.Discard,
+			}
+			if tt.statuses[0] == http.StatusTooManyRequests {
+				// The delay would time the test out if Retry-After wasn't
+				// used instead.
+				opts.RetryDelay = time.Hour
+			} else {
+				opts.RetryDelay = time.Millisecond
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			err := Publish(ctx, opts, testPublishBundle())
+			if tt.problem == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tt.problem != "" && (err == nil || !strings.Contains(err.Error(), tt.problem)) {
+				t.Fatalf("expected an error containing %q, got %v", tt.problem, err)
+			}
+			if len(registry.requests) != tt.requests {
+				t.Errorf("expected %d requests, got %v", tt.requests, registry.requests)
+			}
+		})
+	}
+}
+
+func TestPublishDryRunMakesNoRequests(t *testing.T) {
+	registry := &testRegistry{}
+	var log bytes.Buffer
+	opts := PublishOptions{Endpoint: registry.start(t), DryRun: true, Log: &log}
+	if err := Publish(context.Background(), opts, testPublishBundle()); err != nil {
+		t.Fatal(err)
+	}
+	if len(registry.requests) != 0 {
+		t.Errorf("expected no requests, got %v", registry.requests)
+	}
+	if n := strings.Count(log.String(), "[dry-run]"); n != 3 {
+		t.Errorf("expected 3 requests to be logged, got:\n%s", log.String())
+	}
+}
+
+// cancelWriter cancels a context when a retry is logged.
+type cancelWriter struct {
+	cancel context.CancelFunc
+}
+
+func (w cancelWriter) Write(p []byte) (int, error) {
+	if strings.Contains(string(p), "retrying in") {
+		w.cancel()
+	}
+	return len(p), nil
+}
+
+func TestPublishStopsRetryingWhenCanceled(t *testing.T) {
+	registry := &testRegistry{statuses: []int{http.StatusServiceUnavailable}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opts := PublishOptions{
+		Endpoint:   registry.start(t),
+		Retries:    3,
+		RetryDelay: time.Hour,
+		Log:        cancelWriter{cancel: cancel},
+	}
+
+	err := Publish(ctx, opts, testPublishBundle())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the publish to be canceled, got %v", err)
+	}
+	if len(registry.requests) != 1 {
+		t.Errorf("expected 1 request, got %v", registry.requests)
+	}
+}
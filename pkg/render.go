@@ -0,0 +1,226 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+
+	"github.com/pgavlin/goldmark"
+	"github.com/pgavlin/goldmark/extension"
+	"github.com/pgavlin/goldmark/renderer/html"
+	docsgen "github.com/pulumi/pulumi/pkg/v3/codegen/docs"
+)
+
+const (
+	// navScriptFile is the script that defines the package tree of the HTML
+	// docs of a package, which is loaded by each page to build its sidebar.
+	navScriptFile = "nav.js"
+
+	pulumiWebsiteURL = "https://www.pulumi.com"
+)
+
+var (
+	// The Hugo shortcodes of the package docs are rendered as the elements
+	// that the API docs use for their language choosers.
+	chooserShortcodeRegexp      = regexp.MustCompile(`{{[<%]\s*chooser\s+(\w+)\s+"([^"]*)"\s*/?\s*[>%]}}`)
+	choosableShortcodeRegexp    = regexp.MustCompile(`{{[<%]\s*choosable\s+(\w+)\s+"?([^"%>]*?)"?\s*[>%]}}`)
+	choosableEndShortcodeRegexp = regexp.MustCompile(`{{[<%]\s*/choosable\s*[>%]}}`)
+	// shortcodeRegexp matches the other shortcodes, which are dropped.
+	shortcodeRegexp = regexp.MustCompile(`{{[<%]\s*/?\s*[\w-]+[^}]*?[>%]}}`)
+)
+
+// docsPage is a page of the docs rendered to HTML.
+type docsPage struct {
+	Package string
+	Title   string
+	Body    template.HTML
+	// Meta is shown at the top of the landing page of the API docs.
+	Meta *PackageMeta
+	// HomeURL and APIDocsURL are the URLs of the package docs and of the API
+	// docs, which can be relative to the page.
+	HomeURL    string
+	APIDocsURL string
+	// Error is shown at the top of the page if set.
+	Error string
+	// Generation is the generation of the docs that live reload compares
+	// against. Live reload is disabled if 0.
+	Generation int
+}
+
+// renderDocsPage renders the Markdown of a page to HTML. The links to the
+// pages of the package in the registry are pointed to homeURL and apiDocsURL.
+func renderDocsPage(pkgName, file string, contents []byte, homeURL, apiDocsURL string) (docsPage, error) {
+	// The pages of the API docs start with a blank line before their front
+	// matter.
+	contents = bytes.TrimLeft(contents, "\r\n")
+	page := docsPage{
+		Package:    pkgName,
+		Title:      pageTitle(file, contents),
+		HomeURL:    homeURL,
+		APIDocsURL: apiDocsURL,
+	}
+
+	body := string(contents)
+	if fm, _ := parseFrontMatter(body); fm != nil {
+		body = strings.Join(fm.lines[fm.end+1:], "\n")
+	}
+
+	body = convertShortcodes(body)
+	body = rewriteDocsLinks(body, pkgName, homeURL, apiDocsURL)
+
+	md := goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithRendererOptions(html.WithUnsafe()),
+	)
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(body), &buf); err != nil {
+		return page, fmt.Errorf("rendering %s: %w", file, err)
+	}
+	page.Body = template.HTML(buf.String())
+	return page, nil
+}
+
+// convertShortcodes converts the chooser shortcodes to the elements that the
+// API docs use for their language choosers and drops the other shortcodes.
+func convertShortcodes(body string) string {
+	body = chooserShortcodeRegexp.ReplaceAllString(body, `<div><pulumi-chooser type="$1" options="$2"></pulumi-chooser></div>`)
+	body = choosableShortcodeRegexp.ReplaceAllString(body, "\n\n<pulumi-choosable type=\"$1\" values=\"$2\">\n\n")
+	body = choosableEndShortcodeRegexp.ReplaceAllString(body, "\n\n</pulumi-choosable>\n\n")
+	return shortcodeRegexp.ReplaceAllString(body, "")
+}
+
+// rewriteDocsLinks points the links to the pages of a package in the registry
+// to homeURL and apiDocsURL, and the other links to the Pulumi docs and
+// registry to pulumi.com.
+func rewriteDocsLinks(body, pkgName, homeURL, apiDocsURL string) string {
+	registryURL := fmt.Sprintf("/registry/packages/%s/", pkgName)
+	body = strings.NewReplacer(
+		`"`+registryURL+"api-docs/", `"`+apiDocsURL,
+		"("+registryURL+"api-docs/", "("+apiDocsURL,
+		`"`+registryURL, `"`+homeURL,
+		"("+registryURL, "("+homeURL,
+	).Replace(body)
+	return strings.NewReplacer(
+		`"/docs/`, `"`+pulumiWebsiteURL+"/docs/",
+		"(/docs/", "("+pulumiWebsiteURL+"/docs/",
+		`"/registry/`, `"`+pulumiWebsiteURL+"/registry/",
+		"(/registry/", "("+pulumiWebsiteURL+"/registry/",
+	).Replace(body)
+}
+
+// navScript returns the script that defines the package tree used to build
+// the sidebar of the pages.
+func navScript(nav []docsgen.PackageTreeItem) ([]byte, error) {
+	b, err := json.Marshal(nav)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling the package tree: %w", err)
+	}
+	return []byte(fmt.Sprintf("window.pulumiPackageTree = %s;\n", b)), nil
+}
+
+const docsPageStyle = `
+body { margin: 0; display: flex; font-family: sans-serif; line-height: 1.5; }
+nav { width: 300px; min-width: 300px; height: 100vh; overflow: auto; position: sticky; top: 0; padding: 1em; box-sizing: border-box; background: #f5f5f7; font-size: 14px; }
+nav ul { list-style: none; padding-left: 1em; margin: 0; }
+nav > ul { padding-left: 0; }
+nav a { text-decoration: none; color: #333; }
+nav a.module { font-weight: bold; }
+nav a.current { color: #805ac3; }
+main { flex: 1; padding: 1em 2em; max-width: 1000px; overflow-x: auto; }
+pre { background: #f5f5f7; padding: 1em; overflow-x: auto; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ddd; padding: 0.25em 0.5em; }
+dl.package-meta { display: grid; grid-template-columns: max-content auto; gap: 0.25em 1em; }
+dl.package-meta dd { margin: 0; }
+.error { background: #fde8e8; border: 1px solid #e02424; padding: 1em; white-space: pre-wrap; }
+pulumi-chooser button { margin-right: 0.25em; }
+pulumi-chooser button.selected { font-weight: bold; }
+`
+
+var docsPageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }} | {{ .Package }}</title>
+<style>` + docsPageStyle + `</style>
+</head>
+<body>
+<nav>
+<p><a href="{{ .HomeURL }}">{{ .Package }}</a>{{ if ne .HomeURL .APIDocsURL }} &middot; <a href="{{ .APIDocsURL }}">API docs</a>{{ end }}</p>
+</nav>
+<main>
+{{ if .Error }}<div class="error">{{ .Error }}</div>{{ end }}
+<h1>{{ .Title }}</h1>
+{{ with .Meta }}
+{{ if .Description }}<p>{{ .Description }}</p>{{ end }}
+<dl class="package-meta">
+{{ if .Version }}<dt>Version</dt><dd>{{ .Version }}</dd>{{ end }}
+{{ if .Publisher }}<dt>Publisher</dt><dd>{{ .Publisher }}</dd>{{ end }}
+{{ if .PackageStatus }}<dt>Status</dt><dd>{{ .PackageStatus }}</dd>{{ end }}
+{{ if .RepoURL }}<dt>Repository</dt><dd><a href="{{ .RepoURL }}">{{ .RepoURL }}</a></dd>{{ end }}
+</dl>
+{{ end }}
+{{ .Body }}
+</main>
+<script src="{{ .APIDocsURL }}` + navScriptFile + `"></script>
+<script>
+(function() {
+  var apiDocsURL = "{{ .APIDocsURL }}";
+  function buildNav(items, base) {
+    var ul = document.createElement("ul");
+    (items || []).forEach(function(item) {
+      var url = base + item.link;
+      if (url.charAt(url.length - 1) !== "/") { url += "/"; }
+      var li = document.createElement("li");
+      var a = document.createElement("a");
+      a.className = item.type;
+      a.href = url;
+      a.textContent = item.name;
+      if (a.href === location.href || a.href + "index.html" === location.href) { a.className += " current"; }
+      li.appendChild(a);
+      if (item.children) { li.appendChild(buildNav(item.children, url)); }
+      ul.appendChild(li);
+    });
+    return ul;
+  }
+  document.querySelector("nav").appendChild(buildNav(window.pulumiPackageTree, apiDocsURL));
+
+  function select(type, value) {
+    localStorage.setItem("pulumi-" + type, value);
+    document.querySelectorAll("pulumi-chooser[type='" + type + "'] button").forEach(function(b) {
+      b.classList.toggle("selected", b.dataset.value === value);
+    });
+    document.querySelectorAll("pulumi-choosable[type='" + type + "']").forEach(function(c) {
+      c.style.display = c.getAttribute("values").split(",").indexOf(value) >= 0 ? "" : "none";
+    });
+  }
+  var types = {};
+  document.querySelectorAll("pulumi-chooser").forEach(function(chooser) {
+    var type = chooser.getAttribute("type");
+    var options = chooser.getAttribute("options").split(",");
+    options.forEach(function(option) {
+      var b = document.createElement("button");
+      b.textContent = option;
+      b.dataset.value = option;
+      b.onclick = function() { select(type, option); };
+      chooser.appendChild(b);
+    });
+    types[type] = types[type] || localStorage.getItem("pulumi-" + type) || options[0];
+  });
+  Object.keys(types).forEach(function(type) { select(type, types[type]); });
+{{ if .Generation }}
+  var generation = "{{ .Generation }}";
+  setInterval(function() {
+    fetch("/__generation").then(function(r) { return r.text(); }).then(function(g) {
+      if (g !== generation) { location.reload(); }
+    }).catch(function() {});
+  }, 1000);
+{{ end }}
+})();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,192 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// exampleCoverageLanguages are the languages, in the order of the columns
+// of the coverage matrix, that the examples are expected to be written in.
+var exampleCoverageLanguages = []string{"typescript", "python", "go", "csharp", "java", "yaml"}
+
+// ExampleCoverageReport is the example coverage of one or more packages.
+type ExampleCoverageReport struct {
+	Packages []PackageExampleCoverage `json:"packages"`
+}
+
+// PackageExampleCoverage is the example coverage of the resources and
+// functions of a package.
+type PackageExampleCoverage struct {
+	Package string `json:"package"`
+	ExampleCoverageTotals
+	Modules []ModuleExampleCoverage `json:"modules"`
+}
+
+// ModuleExampleCoverage is the example coverage of the resources and
+// functions of a module.
+type ModuleExampleCoverage struct {
+	Module string `json:"module"`
+	ExampleCoverageTotals
+	Members []MemberExampleCoverage `json:"members"`
+}
+
+// ExampleCoverageTotals holds the totals of a package or module.
+type ExampleCoverageTotals struct {
+	// Members is the number of resources and functions.
+	Members int `json:"member_count"`
+	// MembersWithExamples is the number of resources and functions that have
+	// at least one example.
+	MembersWithExamples int `json:"members_with_examples"`
+	// Languages is a map of language to the number of resources and
+	// functions with at least one example in that language.
+	Languages map[string]int `json:"languages"`
+}
+
+// MemberExampleCoverage is the example coverage of a resource or function.
+type MemberExampleCoverage struct {
+	Token string `json:"token"`
+	// Kind is either resource or function.
+	Kind     string `json:"kind"`
+	Examples int    `json:"examples"`
+	// Languages is a map of language to the number of examples with a
+	// snippet in that language.
+	Languages map[string]int `json:"languages"`
+}
+
+// GetExampleCoverage computes the number of examples of each resource and
+// function of a package by language, along with the totals per module.
+func GetExampleCoverage(pulPkg *pschema.Package) PackageExampleCoverage {
+	members := map[string]*MemberExampleCoverage{}
+	var tokens []string
+	addMember := func(token, kind string) {
+		members[token] = &MemberExampleCoverage{Token: token, Kind: kind, Languages: map[string]int{}}
+		tokens = append(tokens, token)
+	}
+
+	if pulPkg.Provider != nil {
+		addMember(pulPkg.Provider.Token, "resource")
+	}
+	for _, r := range pulPkg.Resources {
+		addMember(r.Token, "resource")
+	}
+	for _, f := range pulPkg.Functions {
+		if !f.IsMethod {
+			addMember(f.Token, "function")
+		}
+	}
+
+	for _, e := range GetExamples(pulPkg) {
+		m, ok := members[e.Token]
+		if !ok {
+			continue
+		}
+		m.Examples++
+		for lang := range e.Snippets {
+			m.Languages[lang]++
+		}
+	}
+
+	byModule := map[string]*ModuleExampleCoverage{}
+	for _, token := range tokens {
+		module := pulPkg.TokenToModule(token)
+		if module == "" {
+			module = "index"
+		}
+		mc, ok := byModule[module]
+		if !ok {
+			mc = &ModuleExampleCoverage{Module: module, ExampleCoverageTotals: newExampleCoverageTotals()}
+			byModule[module] = mc
+		}
+		mc.Members = append(mc.Members, *members[token])
+		mc.add(*members[token])
+	}
+
+	coverage := PackageExampleCoverage{Package: pulPkg.Name, ExampleCoverageTotals: newExampleCoverageTotals()}
+	for _, mc := range byModule {
+		sort.Slice(mc.Members, func(i, j int) bool {
+			return mc.Members[i].Token < mc.Members[j].Token
+		})
+		for _, m := range mc.Members {
+			coverage.add(m)
+		}
+		coverage.Modules = append(coverage.Modules, *mc)
+	}
+	sort.Slice(coverage.Modules, func(i, j int) bool {
+		return coverage.Modules[i].Module < coverage.Modules[j].Module
+	})
+
+	return coverage
+}
+
+func newExampleCoverageTotals() ExampleCoverageTotals {
+	return ExampleCoverageTotals{Languages: map[string]int{}}
+}
+
+func (t *ExampleCoverageTotals) add(m MemberExampleCoverage) {
+	t.Members++
+	if m.Examples > 0 {
+		t.MembersWithExamples++
+	}
+	for lang := range m.Languages {
+		t.Languages[lang]++
+	}
+}
+
+// JSON returns the report as indented JSON.
+func (r *ExampleCoverageReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// Markdown returns the report as Markdown tables. Each package has a table
+// of the totals per module followed by the matrix of its resources and
+// functions.
+func (r *ExampleCoverageReport) Markdown() []byte {
+	var b strings.Builder
+	b.WriteString("# Example coverage\n")
+
+	header := func(first string) {
+		b.WriteString(fmt.Sprintf("\n| %s | Examples | %s |\n", first, strings.Join(exampleCoverageLanguages, " | ")))
+		b.WriteString(strings.Repeat("| --- ", len(exampleCoverageLanguages)+2) + "|\n")
+	}
+	totalsRow := func(name string, t ExampleCoverageTotals) {
+		cells := []string{name, coverageCell(t.MembersWithExamples, t.Members)}
+		for _, lang := range exampleCoverageLanguages {
+			cells = append(cells, coverageCell(t.Languages[lang], t.Members))
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+
+	for _, p := range r.Packages {
+		b.WriteString(fmt.Sprintf("\n## %s\n", p.Package))
+
+		header("Module")
+		for _, m := range p.Modules {
+			totalsRow(m.Module, m.ExampleCoverageTotals)
+		}
+		totalsRow("**Total**", p.ExampleCoverageTotals)
+
+		header("Resource or function")
+		for _, m := range p.Modules {
+			for _, member := range m.Members {
+				cells := []string{fmt.Sprintf("`%s`", member.Token), fmt.Sprint(member.Examples)}
+				for _, lang := range exampleCoverageLanguages {
+					cells = append(cells, fmt.Sprint(member.Languages[lang]))
+				}
+				b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+			}
+		}
+	}
+
+	return []byte(b.String())
+}
+
+func coverageCell(count, total int) string {
+	if total == 0 {
+		return "0/0"
+	}
+	return fmt.Sprintf("%d/%d (%d%%)", count, total, count*100/total)
+}
@@ -0,0 +1,103 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// FetchPackageSpec downloads the schema of a package from its repo at the
+// given version.
+func FetchPackageSpec(ctx context.Context, repoURL, version, schemaFile string) (*pschema.PackageSpec, error) {
+	repoSlug, err := getRepoSlug(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// we should be able to take the repo URL + the version + the schema url and
+	// construct a file that we can download and read
+	schemaFilePath := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s", repoSlug, version, schemaFile)
+	resp, err := HTTPGet(ctx, schemaFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("downloading schema file from %s: %w", schemaFile, err)
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("downloading schema file from %s: %s", schemaFilePath, resp.Status)
+	}
+
+	schema, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading contents of schema file: %w", err)
+	}
+
+	spec, err := ParsePackageSpec(schema, schemaFile)
+	if err != nil {
+		return nil, err
+	}
+	spec.Version = version
+
+	return spec, nil
+}
+
+// ParsePackageSpec parses the contents of a schema file, which can be in
+// either JSON or YAML format.
+func ParsePackageSpec(schema []byte, schemaFile string) (*pschema.PackageSpec, error) {
+	// The source schema can be in YAML format. If that's the case
+	// convert it to JSON first.
+	if strings.HasSuffix(schemaFile, ".yaml") || strings.HasSuffix(schemaFile, ".yml") {
+		var err error
+		schema, err = yaml.YAMLToJSON(schema)
+		if err != nil {
+			return nil, fmt.Errorf("reading YAML schema: %w", err)
+		}
+	}
+
+	spec := &pschema.PackageSpec{}
+	if err := json.Unmarshal(schema, spec); err != nil {
+		return nil, fmt.Errorf("unmarshalling schema into a PackageSpec: %w", err)
+	}
+
+	return spec, nil
+}
+
+// ReadPackageSpec reads the schema of a package from a local file.
+func ReadPackageSpec(schemaFile string) (*pschema.PackageSpec, error) {
+	schema, err := os.ReadFile(schemaFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading schema file %s: %w", schemaFile, err)
+	}
+
+	return ParsePackageSpec(schema, schemaFile)
+}
+
+// LoadPackage loads and imports the schema of a package. If repoSlug is
+// empty, schemaFile is a local file. Otherwise schemaFile is the path of
+// the schema relative to the root of the repo at the given version. The
+// packages referenced by the schema are loaded with the loader, if any.
+func LoadPackage(ctx context.Context, schemaFile, repoSlug, version string, loader pschema.Loader) (*pschema.Package, error) {
+	var spec *pschema.PackageSpec
+	var err error
+	if repoSlug == "" {
+		spec, err = ReadPackageSpec(schemaFile)
+	} else {
+		spec, err = FetchPackageSpec(ctx, repoSlug, version, schemaFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pulPkg, err := bindPackageSpec(spec, loader)
+	if err != nil {
+		return nil, fmt.Errorf("error importing package spec: %w", err)
+	}
+
+	return pulPkg, nil
+}
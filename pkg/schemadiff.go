@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// SchemaDiffStats counts the members of one kind that were added, removed or
+// changed between two versions of a schema.
+type SchemaDiffStats struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+	Changed int `json:"changed"`
+}
+
+// SchemaDiff summarizes the differences between two versions of a schema.
+type SchemaDiff struct {
+	Resources SchemaDiffStats `json:"resources"`
+	Functions SchemaDiffStats `json:"functions"`
+	Types     SchemaDiffStats `json:"types"`
+}
+
+// DiffPackageSpecs compares the resources, functions and types of two
+// versions of a schema.
+func DiffPackageSpecs(old, new *pschema.PackageSpec) (SchemaDiff, error) {
+	var diff SchemaDiff
+	var err error
+	if diff.Resources, err = diffMembers(old.Resources, new.Resources); err != nil {
+		return diff, fmt.Errorf("comparing the resources: %w", err)
+	}
+	if diff.Functions, err = diffMembers(old.Functions, new.Functions); err != nil {
+		return diff, fmt.Errorf("comparing the functions: %w", err)
+	}
+	if diff.Types, err = diffMembers(old.Types, new.Types); err != nil {
+		return diff, fmt.Errorf("comparing the types: %w", err)
+	}
+	return diff, nil
+}
+
+// diffMembers compares the members of a schema keyed by their token. A member
+// has changed if its spec is different in any way, including its docs.
+func diffMembers(old, new interface{}) (SchemaDiffStats, error) {
+	var stats SchemaDiffStats
+	oldSpecs, err := memberSpecs(old)
+	if err != nil {
+		return stats, err
+	}
+	newSpecs, err := memberSpecs(new)
+	if err != nil {
+		return stats, err
+	}
+
+	for token, newSpec := range newSpecs {
+		oldSpec, ok := oldSpecs[token]
+		switch {
+		case !ok:
+			stats.Added++
+		case !bytes.Equal(oldSpec, newSpec):
+			stats.Changed++
+		}
+	}
+	for token := range oldSpecs {
+		if _, ok := newSpecs[token]; !ok {
+			stats.Removed++
+		}
+	}
+	return stats, nil
+}
+
+// memberSpecs returns the JSON of each member of a map of members keyed by
+// their token.
+func memberSpecs(members interface{}) (map[string]json.RawMessage, error) {
+	b, err := json.Marshal(members)
+	if err != nil {
+		return nil, err
+	}
+	specs := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &specs); err != nil {
+		return nil, err
+	}
+	return specs, nil
+}
+
+// Empty returns true if there are no differences.
+func (d SchemaDiff) Empty() bool {
+	return d == SchemaDiff{}
+}
+
+// String returns a line per kind of member, e.g.
+// "Resources: 2 added, 0 removed, 5 changed".
+func (d SchemaDiff) String() string {
+	var sb strings.Builder
+	for _, kind := range []struct {
+		name  string
+		stats SchemaDiffStats
+	}{
+		{"Resources", d.Resources},
+		{"Functions", d.Functions},
+		{"Types", d.Types},
+	} {
+		fmt.Fprintf(&sb, "%s: %d added, %d removed, %d changed\n", kind.name,
+			kind.stats.Added, kind.stats.Removed, kind.stats.Changed)
+	}
+	return sb.String()
+}
@@ -0,0 +1,369 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/pulumi/pulumi-java/pkg/codegen/java"
+	"github.com/pulumi/pulumi/pkg/v3/codegen"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/dotnet"
+	go_gen "github.com/pulumi/pulumi/pkg/v3/codegen/go"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/nodejs"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/python"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+const (
+	searchManifestFileName = "manifest.json"
+	// maxSearchDescriptionLength is the length after which the description of
+	// a search record is truncated.
+	maxSearchDescriptionLength = 300
+)
+
+// SearchRecord is an entry of the search index. The records are a flat list
+// of documents that can be loaded into Lunr (using ObjectID as the ref),
+// Pagefind (as custom records) or Algolia (as-is).
+type SearchRecord struct {
+	ObjectID string `json:"objectID"`
+	Package  string `json:"package"`
+	// Kind is one of resource, function or type.
+	Kind   string `json:"kind"`
+	Token  string `json:"token"`
+	Module string `json:"module"`
+	Name   string `json:"name"`
+	// Names is a map of language to the name of a function in that
+	// language. Resources and types have the same name in every language
+	// and only set Name.
+	Names       map[string]string `json:"names,omitempty"`
+	Description string            `json:"description"`
+	// Properties holds the names of the inputs and outputs in every language
+	// so that resources can be found by their property names.
+	Properties []string `json:"properties,omitempty"`
+	URL        string   `json:"url"`
+}
+
+// SearchManifest lists the shards of the registry-wide search index.
+type SearchManifest struct {
+	Shards []SearchManifestEntry `json:"shards"`
+}
+
+// SearchManifestEntry describes the search index shard of a package.
+type SearchManifestEntry struct {
+	Package string `json:"package"`
+	File    string `json:"file"`
+	Records int    `json:"records"`
+}
+
+// apiDocsURLPath returns the URL path that the API docs of a package are
+// served from by the registry.
+func apiDocsURLPath(pkgName, major string) string {
+	p := fmt.Sprintf("/registry/packages/%s/api-docs/", pkgName)
+	if major != "" {
+		p += major + "/"
+	}
+	return p
+}
+
+// docsPageURLPath returns the URL path of a page in the docs file map.
+func docsPageURLPath(baseURLPath, file string) string {
+	dir := path.Dir(file)
+	if dir == "." {
+		return baseURLPath
+	}
+	return baseURLPath + dir + "/"
+}
+
+// buildSearchIndex creates the search records of the resources, functions
+// and types of a package. Only symbols that have a page in the generated
+// files are included.
+func buildSearchIndex(pulPkg *pschema.Package, files map[string][]byte, baseURLPath string) []SearchRecord {
+	pages := map[string]bool{}
+	// pagesByName maps the name of a page to the paths of the pages with
+	// that name across all modules.
+	pagesByName := map[string][]string{}
+	for f := range files {
+		pages[f] = true
+		name := path.Base(path.Dir(f))
+		pagesByName[name] = append(pagesByName[name], f)
+	}
+
+	// findPage returns the path of the page of the symbol with the given name.
+	findPage := func(module, name string) (string, bool) {
+		p := path.Join(module, name, "_index.md")
+		if pages[p] {
+			return p, true
+		}
+
+		// Some packages (e.g. kubernetes) override the module directory, so
+		// fall back to a unique match on the name of the page.
+		if matches := pagesByName[name]; len(matches) == 1 {
+			return matches[0], true
+		}
+		return "", false
+	}
+
+	var records []SearchRecord
+	// typePages maps the token of an object or enum type to the page that
+	// documents it as a supporting type.
+	typePages := map[string]string{}
+	visitTypes := func(page string, properties []*pschema.Property) {
+		codegen.VisitTypeClosure(properties, func(t pschema.Type) {
+			var token string
+			switch t := t.(type) {
+			case *pschema.ObjectType:
+				if t.IsInputShape() {
+					return
+				}
+				token = t.Token
+			case *pschema.EnumType:
+				token = t.Token
+			default:
+				return
+			}
+			if _, ok := typePages[token]; !ok {
+				typePages[token] = page
+			}
+		})
+	}
+
+	resources := pulPkg.Resources
+	if pulPkg.Provider != nil {
+		resources = append([]*pschema.Resource{pulPkg.Provider}, resources...)
+	}
+	for _, r := range resources {
+		name := tokenName(r.Token)
+		if r.IsProvider {
+			name = "Provider"
+		}
+		module := pulPkg.TokenToModule(r.Token)
+		pageName := strings.ToLower(name)
+		if pageName == "index" {
+			pageName = "--index"
+		}
+
+		page, ok := findPage(module, pageName)
+		if !ok {
+			glog.V(2).Infof("No docs page found for resource %s, skipping it in the search index", r.Token)
+			continue
+		}
+
+		properties := append(append([]*pschema.Property{}, r.InputProperties...), r.Properties...)
+		visitTypes(page, properties)
+
+		records = append(records, SearchRecord{
+			ObjectID:    fmt.Sprintf("%s:resource:%s", pulPkg.Name, r.Token),
+			Package:     pulPkg.Name,
+			Kind:        "resource",
+			Token:       r.Token,
+			Module:      module,
+			Name:        name,
+			Description: shortDescription(r.Comment),
+			Properties:  propertyNames(properties),
+			URL:         docsPageURLPath(baseURLPath, page),
+		})
+	}
+
+	for _, f := range pulPkg.Functions {
+		if f.IsMethod {
+			continue
+		}
+
+		module := pulPkg.TokenToModule(f.Token)
+		page, ok := findPage(module, strings.ToLower(tokenName(f.Token)))
+		if !ok {
+			glog.V(2).Infof("No docs page found for function %s, skipping it in the search index", f.Token)
+			continue
+		}
+
+		var properties []*pschema.Property
+		if f.Inputs != nil {
+			properties = append(properties, f.Inputs.Properties...)
+		}
+		if f.Outputs != nil {
+			properties = append(properties, f.Outputs.Properties...)
+		}
+		visitTypes(page, properties)
+
+		records = append(records, SearchRecord{
+			ObjectID:    fmt.Sprintf("%s:function:%s", pulPkg.Name, f.Token),
+			Package:     pulPkg.Name,
+			Kind:        "function",
+			Token:       f.Token,
+			Module:      module,
+			Name:        tokenName(f.Token),
+			Names:       functionNames(module, f),
+			Description: shortDescription(f.Comment),
+			Properties:  propertyNames(properties),
+			URL:         docsPageURLPath(baseURLPath, page),
+		})
+	}
+
+	for _, t := range pulPkg.Types {
+		var token, comment string
+		var properties []*pschema.Property
+		switch t := t.(type) {
+		case *pschema.ObjectType:
+			if t.IsInputShape() {
+				continue
+			}
+			token, comment, properties = t.Token, t.Comment, t.Properties
+		case *pschema.EnumType:
+			token, comment = t.Token, t.Comment
+		default:
+			continue
+		}
+
+		page, ok := typePages[token]
+		if !ok {
+			continue
+		}
+
+		name := strings.Title(tokenName(token))
+		records = append(records, SearchRecord{
+			ObjectID:    fmt.Sprintf("%s:type:%s", pulPkg.Name, token),
+			Package:     pulPkg.Name,
+			Kind:        "type",
+			Token:       token,
+			Module:      pulPkg.TokenToModule(token),
+			Name:        name,
+			Description: shortDescription(comment),
+			Properties:  propertyNames(properties),
+			URL:         docsPageURLPath(baseURLPath, page) + "#" + strings.ToLower(name),
+		})
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ObjectID < records[j].ObjectID
+	})
+	return records
+}
+
+// writeSearchIndex writes the search records of a package to its shard in
+// outDir and adds the shard to the manifest of the search index.
+func writeSearchIndex(outDir, pkgName string, records []SearchRecord) error {
+	if records == nil {
+		records = []SearchRecord{}
+	}
+	b, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("marshalling the search index: %w", err)
+	}
+
+	shardFileName := fmt.Sprintf("%s.json", pkgName)
+	if err := EmitFile(outDir, shardFileName, b); err != nil {
+		return fmt.Errorf("writing the search index: %w", err)
+	}
+
+	manifest := SearchManifest{}
+	manifestPath := filepath.Join(outDir, searchManifestFileName)
+	b, err = os.ReadFile(manifestPath)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(b, &manifest); err != nil {
+			return fmt.Errorf("unmarshalling the search index manifest %s: %w", manifestPath, err)
+		}
+	case !os.IsNotExist(err):
+		return fmt.Errorf("reading the search index manifest %s: %w", manifestPath, err)
+	}
+
+	shards := []SearchManifestEntry{{Package: pkgName, File: shardFileName, Records: len(records)}}
+	for _, s := range manifest.Shards {
+		if s.Package != pkgName {
+			shards = append(shards, s)
+		}
+	}
+	sort.Slice(shards, func(i, j int) bool {
+		return shards[i].Package < shards[j].Package
+	})
+	manifest.Shards = shards
+
+	b, err = json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling the search index manifest: %w", err)
+	}
+	if err := EmitFile(outDir, searchManifestFileName, b); err != nil {
+		return fmt.Errorf("writing the search index manifest: %w", err)
+	}
+
+	return nil
+}
+
+// tokenName returns the member name of a Pulumi token.
+func tokenName(tok string) string {
+	components := strings.Split(tok, ":")
+	return components[len(components)-1]
+}
+
+// searchLanguageHelpers are the helpers that name the functions and
+// properties of a package in each language of the search index.
+var searchLanguageHelpers = map[string]codegen.DocLanguageHelper{
+	"nodejs": nodejs.DocLanguageHelper{},
+	"python": python.DocLanguageHelper{},
+	"go":     go_gen.DocLanguageHelper{},
+	"csharp": dotnet.DocLanguageHelper{},
+	"java":   java.DocLanguageHelper{},
+}
+
+// functionNames returns the name of a function in each language.
+func functionNames(module string, f *pschema.Function) map[string]string {
+	names := map[string]string{}
+	for lang, helper := range searchLanguageHelpers {
+		name := helper.GetFunctionName(module, f)
+		if lang == "go" {
+			name = go_gen.Title(name)
+		}
+		names[lang] = name
+	}
+	return names
+}
+
+// propertyNames returns the names of the properties in every language,
+// e.g. both instance_type and InstanceType for instanceType.
+func propertyNames(properties []*pschema.Property) []string {
+	seen := map[string]bool{}
+	var names []string
+	add := func(name string) {
+		if name == "" || seen[name] {
+			return
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	for _, p := range properties {
+		add(p.Name)
+		for lang, helper := range searchLanguageHelpers {
+			name, err := helper.GetPropertyName(p)
+			if err != nil {
+				glog.V(2).Infof("Getting the %s name of property %s: %v", lang, p.Name, err)
+				continue
+			}
+			add(name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// shortDescription returns the first paragraph of a description without
+// the examples, truncated to a length suitable for search results.
+func shortDescription(comment string) string {
+	if i := strings.Index(comment, "{{% examples %}}"); i >= 0 {
+		comment = comment[:i]
+	}
+	comment = strings.TrimSpace(comment)
+	if i := strings.Index(comment, "\n\n"); i >= 0 {
+		comment = comment[:i]
+	}
+	comment = strings.Join(strings.Fields(comment), " ")
+
+	if runes := []rune(comment); len(runes) > maxSearchDescriptionLength {
+		comment = strings.TrimSpace(string(runes[:maxSearchDescriptionLength])) + "..."
+	}
+	return comment
+}
@@ -0,0 +1,115 @@
+package pkg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	sitemapFileName = "sitemap.xml"
+	sitemapXMLNS    = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	// maxSitemapURLs is the maximum number of URLs allowed in a single
+	// sitemap file by the sitemaps protocol.
+	maxSitemapURLs = 50000
+)
+
+// Sitemap collects the URLs of the generated API docs pages so that they
+// can be written as a sitemap once all of the packages are generated.
+type Sitemap struct {
+	// BaseURL is the scheme and host that the registry is served from,
+	// e.g. https://www.pulumi.com.
+	BaseURL string
+
+	urls []sitemapURL
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	XMLNS    string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapURL `xml:"sitemap"`
+}
+
+// NewSitemap returns an empty sitemap for pages served from baseURL.
+func NewSitemap(baseURL string) *Sitemap {
+	return &Sitemap{BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// addPages adds the pages in the docs file map of a package to the sitemap.
+// updatedOn is the Unix timestamp of the last update of the package, if
+// known.
+func (s *Sitemap) addPages(baseURLPath string, files map[string][]byte, updatedOn int64) {
+	lastMod := ""
+	if updatedOn > 0 {
+		lastMod = time.Unix(updatedOn, 0).UTC().Format("2006-01-02")
+	}
+
+	for f := range files {
+		if !strings.HasSuffix(f, ".md") {
+			continue
+		}
+		s.urls = append(s.urls, sitemapURL{
+			Loc:     s.BaseURL + docsPageURLPath(baseURLPath, f),
+			LastMod: lastMod,
+		})
+	}
+}
+
+// Write writes the sitemap to outDir. If there are more URLs than allowed
+// in a single sitemap, the URLs are split across sitemap-<n>.xml files and
+// sitemap.xml is written as the sitemap index, which expects the sitemap
+// files to be served from the root of the base URL.
+func (s *Sitemap) Write(outDir string) error {
+	urls := append([]sitemapURL{}, s.urls...)
+	sort.Slice(urls, func(i, j int) bool {
+		return urls[i].Loc < urls[j].Loc
+	})
+
+	if len(urls) <= maxSitemapURLs {
+		return writeXMLFile(outDir, sitemapFileName, sitemapURLSet{XMLNS: sitemapXMLNS, URLs: urls})
+	}
+
+	index := sitemapIndex{XMLNS: sitemapXMLNS}
+	for i := 0; i*maxSitemapURLs < len(urls); i++ {
+		end := (i + 1) * maxSitemapURLs
+		if end > len(urls) {
+			end = len(urls)
+		}
+
+		fileName := fmt.Sprintf("sitemap-%d.xml", i+1)
+		err := writeXMLFile(outDir, fileName, sitemapURLSet{XMLNS: sitemapXMLNS, URLs: urls[i*maxSitemapURLs : end]})
+		if err != nil {
+			return err
+		}
+		index.Sitemaps = append(index.Sitemaps, sitemapURL{Loc: fmt.Sprintf("%s/%s", s.BaseURL, fileName)})
+	}
+
+	return writeXMLFile(outDir, sitemapFileName, index)
+}
+
+func writeXMLFile(outDir, fileName string, v interface{}) error {
+	b, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling %s: %w", fileName, err)
+	}
+
+	contents := append([]byte(xml.Header), b...)
+	contents = append(contents, '\n')
+	if err := EmitFile(outDir, fileName, contents); err != nil {
+		return fmt.Errorf("writing %s: %w", fileName, err)
+	}
+	return nil
+}
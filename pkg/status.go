@@ -0,0 +1,106 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+const statusKeywordPrefix = "status/"
+
+// GetPackageStatus determines the lifecycle status of a package. A
+// status/<status> keyword in the schema takes precedence, followed by
+// the pre-release identifier of the version (e.g. -alpha.1, -beta.2).
+// Packages with a v0.x version are considered to be in public preview
+// and everything else is GA.
+func GetPackageStatus(keywords []string, version string) (PackageStatus, error) {
+	for _, k := range keywords {
+		if !strings.HasPrefix(k, statusKeywordPrefix) {
+			continue
+		}
+
+		statusName := strings.TrimPrefix(k, statusKeywordPrefix)
+		status, ok := PackageStatusNameMap[statusName]
+		if !ok {
+			return "", fmt.Errorf("invalid status tag %s", k)
+		}
+		return status, nil
+	}
+
+	v := strings.TrimPrefix(version, "v")
+	// Build metadata has no bearing on the status.
+	if i := strings.Index(v, "+"); i >= 0 {
+		v = v[:i]
+	}
+	if i := strings.Index(v, "-"); i >= 0 {
+		prerelease := strings.ToLower(v[i+1:])
+		switch {
+		case strings.HasPrefix(prerelease, "alpha"):
+			return PackageStatusAlpha, nil
+		case strings.HasPrefix(prerelease, "beta"):
+			return PackageStatusBeta, nil
+		default:
+			return PackageStatusPublicPreview, nil
+		}
+	}
+
+	if strings.HasPrefix(v, "0.") {
+		return PackageStatusPublicPreview, nil
+	}
+
+	return PackageStatusGA, nil
+}
+
+// IsDeprecatedStatus returns true if users should be discouraged from
+// using a package with the given status.
+func IsDeprecatedStatus(status PackageStatus) bool {
+	return status == PackageStatusDeprecated || status == PackageStatusEndOfLife
+}
+
+// deprecationBanner returns the notice that is added to the top of each
+// generated page of a deprecated package. Returns nil if the package is
+// not deprecated.
+func deprecationBanner(pkgName string, status PackageStatus, message, supersededBy string) []byte {
+	if !IsDeprecatedStatus(status) {
+		return nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("{{% notes type=\"warning\" %}}\n")
+	if status == PackageStatusEndOfLife {
+		fmt.Fprintf(&sb, "The %s package has reached end of life and is no longer maintained.", pkgName)
+	} else {
+		fmt.Fprintf(&sb, "The %s package is deprecated.", pkgName)
+	}
+	if message != "" {
+		sb.WriteString(" ")
+		sb.WriteString(message)
+	}
+	if supersededBy != "" {
+		fmt.Fprintf(&sb, " Use the [%s](/registry/packages/%s/) package instead.", supersededBy, supersededBy)
+	}
+	sb.WriteString("\n{{% /notes %}}\n")
+
+	return []byte(sb.String())
+}
+
+// injectBanner inserts the banner right after the front matter of a
+// generated page.
+func injectBanner(contents, banner []byte) []byte {
+	s := string(contents)
+	offset := 0
+	// Skip any leading blank lines before the front matter.
+	trimmed := strings.TrimLeft(s, "\n")
+	if strings.HasPrefix(trimmed, "---\n") {
+		start := len(s) - len(trimmed)
+		if end := strings.Index(trimmed[4:], "\n---\n"); end >= 0 {
+			offset = start + 4 + end + len("\n---\n")
+		}
+	}
+
+	out := make([]byte, 0, len(contents)+len(banner)+1)
+	out = append(out, s[:offset]...)
+	out = append(out, '\n')
+	out = append(out, banner...)
+	out = append(out, s[offset:]...)
+	return out
+}
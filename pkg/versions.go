@@ -0,0 +1,218 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/blang/semver"
+)
+
+const versionIndexFileName = "versions.json"
+
+// VersionIndex is the index of the versioned API docs of a package. It is
+// written to the root of the package's API docs directory so that the
+// registry can render a version picker.
+type VersionIndex struct {
+	// Latest is the major version that the unversioned docs and nav tree
+	// belong to.
+	Latest   string              `json:"latest"`
+	Versions []VersionIndexEntry `json:"versions"`
+}
+
+// VersionIndexEntry describes the API docs of a single major version of
+// a package.
+type VersionIndexEntry struct {
+	// Major is the major version, e.g. v5.
+	Major string `json:"major"`
+	// Version is the full version the docs were generated from, e.g. v5.4.0.
+	Version string `json:"version"`
+	// Path is the path of the docs relative to the package's API docs
+	// directory.
+	Path string `json:"path"`
+	// NavFile is the path of the package tree JSON file relative to the
+	// package tree output directory.
+	NavFile string `json:"nav_file"`
+}
+
+// MajorVersion returns the major version of a version with the "v" prefix,
+// e.g. v5 for v5.4.0.
+func MajorVersion(version string) (string, error) {
+	v, err := semver.ParseTolerant(version)
+	if err != nil {
+		return "", fmt.Errorf("parsing version %s: %w", version, err)
+	}
+
+	return fmt.Sprintf("v%d", v.Major), nil
+}
+
+// MergeVersions adds version to the list of versions keeping only the most
+// recent version of each major version. The result is sorted newest first.
+func MergeVersions(versions []string, version string) ([]string, error) {
+	newest := map[uint64]semver.Version{}
+	names := map[uint64]string{}
+	for _, name := range append(versions, version) {
+		if name == "" {
+			continue
+		}
+
+		v, err := semver.ParseTolerant(name)
+		if err != nil {
+			return nil, fmt.Errorf("parsing version %s: %w", name, err)
+		}
+
+		if existing, ok := newest[v.Major]; ok && existing.GTE(v) {
+			continue
+		}
+		newest[v.Major] = v
+		names[v.Major] = name
+	}
+
+	majors := make([]uint64, 0, len(names))
+	for major := range names {
+		majors = append(majors, major)
+	}
+	sort.Slice(majors, func(i, j int) bool {
+		return majors[i] > majors[j]
+	})
+
+	merged := make([]string, 0, len(majors))
+	for _, major := range majors {
+		merged = append(merged, names[major])
+	}
+	return merged, nil
+}
+
+// readVersionIndex reads the versions.json file of docsOutDir. Returns an
+// empty index if it doesn't exist.
+func readVersionIndex(docsOutDir string) (*VersionIndex, error) {
+	index := &VersionIndex{}
+	indexPath := filepath.Join(docsOutDir, versionIndexFileName)
+	b, err := os.ReadFile(indexPath)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(b, index); err != nil {
+			return nil, fmt.Errorf("unmarshalling the version index %s: %w", indexPath, err)
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("reading the version index %s: %w", indexPath, err)
+	}
+	return index, nil
+}
+
+// mergeVersionIndex returns the entries of the index with entry replacing the
+// entry of the same major version, sorted newest first.
+func mergeVersionIndex(index *VersionIndex, entry VersionIndexEntry) ([]VersionIndexEntry, error) {
+	versions := []VersionIndexEntry{entry}
+	for _, e := range index.Versions {
+		if e.Major != entry.Major {
+			versions = append(versions, e)
+		}
+	}
+
+	var sortErr error
+	sort.Slice(versions, func(i, j int) bool {
+		vi, err := semver.ParseTolerant(versions[i].Version)
+		if err != nil {
+			sortErr = err
+		}
+		vj, err := semver.ParseTolerant(versions[j].Version)
+		if err != nil {
+			sortErr = err
+		}
+		return vi.GT(vj)
+	})
+	if sortErr != nil {
+		return nil, fmt.Errorf("sorting the versions: %w", sortErr)
+	}
+	return versions, nil
+}
+
+// checkVersionRetained returns an error if the docs of version would be
+// removed right after being generated because its major version is older
+// than the keepVersions most recent major versions in the index of
+// docsOutDir.
+func checkVersionRetained(docsOutDir, version string, keepVersions int) error {
+	if keepVersions <= 0 {
+		return nil
+	}
+	major, err := MajorVersion(version)
+	if err != nil {
+		return err
+	}
+	index, err := readVersionIndex(docsOutDir)
+	if err != nil {
+		return err
+	}
+	versions, err := mergeVersionIndex(index, VersionIndexEntry{Major: major, Version: version})
+	if err != nil {
+		return err
+	}
+	for i, e := range versions {
+		if i < keepVersions && e.Major == major {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is older than the %d most recent major versions that are kept", version, keepVersions)
+}
+
+// updateVersionIndex records the docs generated for version in the
+// versions.json file of docsOutDir and removes the docs and nav trees of
+// the major versions that exceed the retention policy. A keepVersions of
+// 0 retains all major versions. The major version of version is never
+// removed. Returns the updated index.
+func updateVersionIndex(docsOutDir, packageTreeJSONOutDir, pkgName, version string, keepVersions int) (*VersionIndex, error) {
+	major, err := MajorVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := readVersionIndex(docsOutDir)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := VersionIndexEntry{
+		Major:   major,
+		Version: version,
+		Path:    major + "/",
+		NavFile: filepath.ToSlash(filepath.Join(pkgName, major+".json")),
+	}
+	versions, err := mergeVersionIndex(index, entry)
+	if err != nil {
+		return nil, fmt.Errorf("updating the version index of %s: %w", docsOutDir, err)
+	}
+
+	if keepVersions > 0 && len(versions) > keepVersions {
+		var retained []VersionIndexEntry
+		for i, e := range versions {
+			if i < keepVersions || e.Major == major {
+				retained = append(retained, e)
+				continue
+			}
+			if err := os.RemoveAll(filepath.Join(docsOutDir, e.Major)); err != nil {
+				return nil, fmt.Errorf("deleting the docs of version %s: %w", e.Version, err)
+			}
+			navFile := filepath.Join(packageTreeJSONOutDir, filepath.FromSlash(e.NavFile))
+			if err := os.Remove(navFile); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("deleting the package tree of version %s: %w", e.Version, err)
+			}
+		}
+		versions = retained
+	}
+
+	index.Versions = versions
+	index.Latest = versions[0].Major
+
+	b, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshalling the version index: %w", err)
+	}
+	if err := EmitFile(docsOutDir, versionIndexFileName, b); err != nil {
+		return nil, fmt.Errorf("writing the version index: %w", err)
+	}
+
+	return index, nil
+}